@@ -32,6 +32,10 @@ func main() {
 		return
 	}
 
+	if args := flag.Args(); len(args) > 0 {
+		os.Exit(runSubcommand(cp, args))
+	}
+
 	ui := tray.NewApp()
 	app.Run(cp, ui)
 }
@@ -61,6 +65,36 @@ func runUninstallFlow(configPath string, purge bool) {
 	showMessageBox("Claude Status 卸载", msg, false)
 }
 
+// runSubcommand 执行命令行子命令（如 attach、backups、doctor、install、provision、status），返回进程退出码。
+// 子命令面向终端使用，结果直接输出到控制台。
+func runSubcommand(configPath string, args []string) int {
+	attachParentConsole()
+
+	var err error
+	switch args[0] {
+	case "attach":
+		err = app.RunAttach(configPath, args[1:], os.Stdout)
+	case "backups":
+		err = app.RunBackups(configPath, args[1:], os.Stdout)
+	case "doctor":
+		err = app.RunDoctor(configPath, os.Stdout)
+	case "install":
+		err = app.RunInstall(configPath, os.Stdout)
+	case "provision":
+		err = app.RunProvision(configPath, args[1:], os.Stdout)
+	case "status":
+		err = app.RunStatus(configPath, args[1:], os.Stdout)
+	default:
+		err = fmt.Errorf("未知命令: %s", args[0])
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "错误: "+err.Error())
+		return 1
+	}
+	return 0
+}
+
 // attachParentConsole 将进程附加到父进程的控制台，
 // 让 -H windowsgui 构建的二进制在从终端启动时也能输出文本。
 // 若没有父控制台（例如双击运行），静默返回，依赖 MessageBox 给用户反馈。
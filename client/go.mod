@@ -6,9 +6,13 @@ require (
 	github.com/kevinburke/ssh_config v1.2.0
 	github.com/lxn/walk v0.0.0-20210112085537-c389da54e794
 	github.com/lxn/win v0.0.0-20210218163916-a377121e959e
+	github.com/pkg/sftp v1.13.6
 	golang.org/x/crypto v0.18.0
 	golang.org/x/sys v0.16.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
-require gopkg.in/Knetic/govaluate.v3 v3.0.0 // indirect
+require (
+	github.com/kr/fs v0.1.0 // indirect
+	gopkg.in/Knetic/govaluate.v3 v3.0.0 // indirect
+)
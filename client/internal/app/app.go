@@ -4,7 +4,6 @@ package app
 
 import (
 	"errors"
-	"fmt"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -13,7 +12,6 @@ import (
 	"time"
 
 	"claude-status/internal/config"
-	"claude-status/internal/installer"
 	"claude-status/internal/logger"
 	"claude-status/internal/monitor"
 	"claude-status/internal/ssh"
@@ -294,12 +292,114 @@ func runConnection(sm *StateMachine, cfg *config.Config, ui UI, sigCh chan os.Si
 	sm.Transition(EventConnectSuccess)
 
 	statusTimeout := int64(cfg.StatusTimeout)
+	notifiers := newNotifiers(cfg, ui)
+	notifications := newNotificationTracker()
+	budgets := newBudgetTracker()
+	var blocks blockTracker
+	ui.UpdateUsageBlock(nil, 0)
+
+	// 控制通道：发送监控设置并定期测量延迟（在后台执行，不阻塞状态更新）
+	go applyMonitorSettings(client, ui, cfg.Monitor)
+	go measureLatency(client, ui)
+	go renewLease(client)
+	pingTicker := time.NewTicker(pingInterval)
+	defer pingTicker.Stop()
+	defer ui.SetLatency(0)
+	var reportedSkew time.Duration
+	defer ui.SetClockSkew(0)
+
+	// 事件日志：请求补发上次断开后的事件，补发完成前不保存实时事件的位置
+	journal := journalKey(cfg)
+	position := loadJournalPosition(journal)
+	from := position
+	var away []monitor.JournalEvent
+	caughtUp := false
+	replayed := make(chan replayResult, 1)
+	go requestReplay(client, from, replayed)
+	handleEvent := func(e monitor.JournalEvent) {
+		if e.Replay {
+			// 只汇总断开后发生的事件（旧版服务端可能补发已收到的事件）
+			if from.After(e) {
+				away = append(away, e)
+			}
+			return
+		}
+		if position.Advance(e) && caughtUp {
+			if err := saveJournalPosition(journal, position); err != nil {
+				logger.Error("保存事件日志位置失败: %v", err)
+			}
+		}
+	}
+
+	// 刷新限流：繁忙时服务端的完整状态很频繁，托盘与悬浮窗口按间隔刷新（通知仍逐条检测）
+	throttle := &updateThrottle{interval: cfg.Monitor.GetUpdateInterval()}
+	var flushTimer *time.Timer
+	var flushC <-chan time.Time
+	loggedReceived := 0
+	defer func() {
+		if flushTimer != nil {
+			flushTimer.Stop()
+		}
+		received, applied := throttle.Stats()
+		logger.Info("状态消息: 收到 %d，刷新 %d", received, applied)
+	}()
 
 	// 主监控循环
 	for {
 		select {
 		case statuses := <-client.StatusChan():
-			processAndUpdateStatus(ui, statuses, statusTimeout)
+			applyCosts(statuses, cfg.Usage)
+			reportedSkew = reportClockSkew(ui, reportedSkew, client.ClockSkew())
+			if apply, wait := throttle.Receive(statuses, time.Now()); apply {
+				processAndUpdateStatus(ui, statuses, statusTimeout, reportedSkew)
+			} else if wait > 0 {
+				flushTimer = time.NewTimer(wait)
+				flushC = flushTimer.C
+			}
+			forwardNotifications(notifiers, notifications.Changed(statuses))
+			forwardNotifications(notifiers, budgets.Exceeded(statuses, cfg.Usage))
+
+		case <-flushC:
+			flushC = nil
+			if statuses, ok := throttle.Flush(time.Now()); ok {
+				processAndUpdateStatus(ui, statuses, statusTimeout, reportedSkew)
+			}
+
+		case block := <-client.UsageBlockChan():
+			ui.UpdateUsageBlock(block, cfg.Usage.BlockLimit)
+			if n := blocks.Reached(block, cfg.Usage, monitor.ServerNow(reportedSkew)); n != nil {
+				forwardNotifications(notifiers, []Notification{*n})
+			}
+
+		case e := <-client.EventChan():
+			handleEvent(e)
+
+		case r := <-replayed:
+			// 补发的事件先于回复到达，先处理 channel 中剩余的事件
+		drain:
+			for {
+				select {
+				case e := <-client.EventChan():
+					handleEvent(e)
+				default:
+					break drain
+				}
+			}
+			if r.err != nil {
+				logger.Error("补发事件失败: %v", r.err)
+			} else {
+				reportAway(ui, notifiers, cfg, from, away)
+				if r.pos.Journal != position.Journal || r.pos.Seq > position.Seq {
+					position = r.pos
+				}
+			}
+			away = nil
+			caughtUp = true
+			if position.Journal != "" {
+				if err := saveJournalPosition(journal, position); err != nil {
+					logger.Error("保存事件日志位置失败: %v", err)
+				}
+			}
 
 		case err := <-client.ErrorChan():
 			errMsg := err.Error()
@@ -333,53 +433,46 @@ func runConnection(sm *StateMachine, cfg *config.Config, ui UI, sigCh chan os.Si
 				NewServer: &server,
 			}
 
-		case <-sigCh:
-			return ConnectionResult{Event: EventUserQuit}
-		}
-	}
-}
+		case s := <-ui.AttachChan():
+			if err := attachSession(cfg, s); err != nil {
+				logger.Error("连接会话失败: %v", err)
+				ui.Notify("无法连接会话", err.Error())
+			}
 
-// processAndUpdateStatus 过滤状态并更新 UI
-func processAndUpdateStatus(ui UI, statuses []monitor.ProjectStatus, statusTimeout int64) {
-	now := time.Now().Unix()
+		case req := <-ui.ReplyChan():
+			// 在后台执行，服务端响应慢时不阻塞状态更新
+			go sendReply(client, ui, req)
 
-	// 过滤掉 stopped 状态和超时的实例
-	filtered := make([]monitor.ProjectStatus, 0, len(statuses))
-	for _, s := range statuses {
-		if s.Status == "stopped" {
-			continue
-		}
-		if statusTimeout > 0 && now-s.UpdatedAt > statusTimeout {
-			continue
-		}
-		filtered = append(filtered, s)
-	}
+		case <-ui.RefreshChan():
+			go resyncStatus(client, ui)
+
+		case s := <-ui.RemoveChan():
+			go removeSession(client, ui, s)
+
+		case <-pingTicker.C:
+			go measureLatency(client, ui)
+			go renewLease(client)
+			// 上一次 ping 的回复同样带有服务端时间，无状态变化时也能更新偏差
+			reportedSkew = reportClockSkew(ui, reportedSkew, client.ClockSkew())
+			if received, applied := throttle.Stats(); received != loggedReceived {
+				loggedReceived = received
+				logger.Info("状态消息: 收到 %d，刷新 %d", received, applied)
+			}
 
-	// 判断是否有项目在工作中
-	hasWorking := false
-	workingCount := 0
-	for _, s := range filtered {
-		if s.Status == "working" {
-			hasWorking = true
-			workingCount++
+		case <-sigCh:
+			return ConnectionResult{Event: EventUserQuit}
 		}
 	}
+}
 
-	// 更新图标
-	if hasWorking {
-		ui.SetIcon("running")
-	} else {
-		ui.SetIcon("input-needed")
-	}
+// processAndUpdateStatus 过滤状态并更新 UI，超时按服务端时钟（本地时间加偏差 skew）计算
+func processAndUpdateStatus(ui UI, statuses []monitor.ProjectStatus, statusTimeout int64, skew time.Duration) {
+	filtered := activeStatuses(statuses, monitor.ServerNow(skew), statusTimeout)
 
-	// 更新状态菜单项
-	if len(filtered) == 0 {
-		ui.SetStatusText("已连接 - 无活动项目")
-	} else if workingCount > 0 {
-		ui.SetStatusText(fmt.Sprintf("运行中 (%d 个项目)", workingCount))
-	} else {
-		ui.SetStatusText(fmt.Sprintf("等待输入 (%d 个项目)", len(filtered)))
-	}
+	// 更新图标与状态菜单项（等待授权单独统计）
+	sum := summarizeStatuses(filtered)
+	ui.SetIcon(sum.Icon)
+	ui.SetStatusText(sum.Text)
 
 	// 更新悬浮窗口
 	ui.UpdatePopup(filtered)
@@ -387,12 +480,7 @@ func processAndUpdateStatus(ui UI, statuses []monitor.ProjectStatus, statusTimeo
 
 // doInstall 执行首次安装，返回是否成功
 func doInstall(cfg *config.Config, ui UI) bool {
-	var inst monitor.Installer
-	if cfg.WSL.Enabled {
-		inst = wsl.NewInstaller(cfg)
-	} else {
-		inst = installer.NewInstaller(cfg)
-	}
+	inst := newInstaller(cfg)
 
 	if err := inst.Connect(); err != nil {
 		logger.Error("安装器连接失败: %v", err)
@@ -421,12 +509,7 @@ func doInstall(cfg *config.Config, ui UI) bool {
 
 // doReinstall 执行重新安装（版本不匹配时），返回是否成功
 func doReinstall(cfg *config.Config, ui UI) bool {
-	var inst monitor.Installer
-	if cfg.WSL.Enabled {
-		inst = wsl.NewInstaller(cfg)
-	} else {
-		inst = installer.NewInstaller(cfg)
-	}
+	inst := newInstaller(cfg)
 
 	if err := inst.Connect(); err != nil {
 		logger.Error("安装器连接失败: %v", err)
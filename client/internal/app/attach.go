@@ -0,0 +1,83 @@
+//go:build windows
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"claude-status/internal/config"
+	"claude-status/internal/logger"
+	"claude-status/internal/monitor"
+)
+
+// AttachUsage attach 子命令的用法说明
+const AttachUsage = `用法: claude-status attach <session>
+
+在新的终端窗口中连接到会话所在的 tmux 窗格或 screen 窗口（SSH 模式使用 ssh -t，
+WSL 模式使用 wsl）。<session> 为会话 ID 或其唯一前缀，可通过 claude-status status 查看。`
+
+// createNewConsole CreateProcess 标志：为子进程创建新的控制台窗口
+const createNewConsole = 0x00000010
+
+// RunAttach 查询服务端会话并在新终端中连接到指定会话
+func RunAttach(configPath string, args []string, w io.Writer) error {
+	if len(args) != 1 || strings.HasPrefix(args[0], "-") {
+		return fmt.Errorf("%s", AttachUsage)
+	}
+
+	if err := logger.Init(); err == nil {
+		defer logger.Close()
+	}
+	logger.Info("RunAttach: configPath=%s session=%s", configPath, args[0])
+
+	cfg, err := loadCommandConfig(configPath)
+	if err != nil {
+		return err
+	}
+	inst, err := connectConfigInstaller(cfg)
+	if err != nil {
+		return err
+	}
+	defer inst.Close()
+
+	output, err := inst.RunScript(statusScript)
+	if err != nil {
+		return fmt.Errorf("%w (output: %s)", err, strings.TrimSpace(output))
+	}
+	statuses, _, _, _, err := parseMonitorOnce(output)
+	if err != nil {
+		return err
+	}
+
+	s, err := findSession(statuses, args[0])
+	if err != nil {
+		return err
+	}
+	if err := attachSession(cfg, s); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "已在新窗口中连接到 %s (%s)\n", s.DisplayName(), s.SessionId)
+	return nil
+}
+
+// attachSession 在新的控制台窗口中运行连接命令，不等待其退出
+func attachSession(cfg *config.Config, s monitor.ProjectStatus) error {
+	name, args, err := attachArgs(cfg, s)
+	if err != nil {
+		return err
+	}
+	logger.Info("attachSession: session=%s %s %q", s.SessionId, name, args)
+
+	cmd := exec.Command(name, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: createNewConsole}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("启动 %s 失败: %w", name, err)
+	}
+	// 异步回收子进程
+	go cmd.Wait()
+	return nil
+}
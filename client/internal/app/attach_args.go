@@ -0,0 +1,67 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"claude-status/internal/config"
+	"claude-status/internal/monitor"
+)
+
+// findSession 按会话 ID 或唯一前缀查找未结束的会话
+func findSession(statuses []monitor.ProjectStatus, query string) (monitor.ProjectStatus, error) {
+	var matches []monitor.ProjectStatus
+	for _, s := range statuses {
+		if s.Status == monitor.StatusStopped {
+			continue
+		}
+		if s.SessionId == query {
+			return s, nil
+		}
+		if strings.HasPrefix(s.SessionId, query) {
+			matches = append(matches, s)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return monitor.ProjectStatus{}, fmt.Errorf("未找到会话: %s", query)
+	case 1:
+		return matches[0], nil
+	default:
+		return monitor.ProjectStatus{}, fmt.Errorf("会话前缀 %s 匹配到 %d 个会话，请输入更长的 ID", query, len(matches))
+	}
+}
+
+// attachArgs 返回在本地终端连接到会话所在 tmux 窗格 / screen 窗口的命令及参数：
+// SSH 模式为 ssh -t，WSL 模式为 wsl -- bash -lc
+func attachArgs(cfg *config.Config, s monitor.ProjectStatus) (string, []string, error) {
+	remote := s.AttachCommand()
+	if remote == "" {
+		if s.Tty != "" {
+			return "", nil, fmt.Errorf("会话未运行在 tmux/screen 中（终端 %s），无法连接", s.Tty)
+		}
+		return "", nil, fmt.Errorf("会话未运行在 tmux/screen 中，无法连接")
+	}
+
+	if cfg.WSL.Enabled {
+		var args []string
+		if cfg.WSL.Distro != "" {
+			args = append(args, "-d", cfg.WSL.Distro)
+		}
+		return "wsl", append(args, "--", "bash", "-lc", remote), nil
+	}
+
+	args := []string{"-t"}
+	if cfg.Server.Port > 0 && cfg.Server.Port != 22 {
+		args = append(args, "-p", strconv.Itoa(cfg.Server.Port))
+	}
+	if cfg.Server.IdentityFile != "" {
+		args = append(args, "-i", cfg.GetIdentityFile())
+	}
+	host := cfg.Server.Host
+	if cfg.Server.User != "" {
+		host = cfg.Server.User + "@" + host
+	}
+	return "ssh", append(args, host, remote), nil
+}
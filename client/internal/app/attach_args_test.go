@@ -0,0 +1,49 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"claude-status/internal/config"
+	"claude-status/internal/monitor"
+)
+
+func TestFindSession(t *testing.T) {
+	statuses := []monitor.ProjectStatus{
+		{SessionId: "abc123", Status: monitor.StatusIdle},
+		{SessionId: "abd456", Status: monitor.StatusWorking},
+		{SessionId: "xyz789", Status: monitor.StatusStopped},
+	}
+
+	if s, err := findSession(statuses, "abd"); err != nil || s.SessionId != "abd456" {
+		t.Errorf("prefix: got %q, %v", s.SessionId, err)
+	}
+	if _, err := findSession(statuses, "ab"); err == nil {
+		t.Error("ambiguous prefix: want error")
+	}
+	if _, err := findSession(statuses, "xyz"); err == nil {
+		t.Error("stopped session: want error")
+	}
+}
+
+func TestAttachArgs(t *testing.T) {
+	s := monitor.ProjectStatus{ScreenSession: "42.main"}
+
+	cfg := &config.Config{Server: config.ServerConfig{Host: "dev", Port: 2222, User: "me"}}
+	name, args, err := attachArgs(cfg, s)
+	want := []string{"-t", "-p", "2222", "me@dev", "screen -x '42.main'"}
+	if err != nil || name != "ssh" || !reflect.DeepEqual(args, want) {
+		t.Errorf("ssh: got %s %q, %v", name, args, err)
+	}
+
+	cfg = &config.Config{WSL: config.WSLConfig{Enabled: true, Distro: "Ubuntu"}}
+	name, args, err = attachArgs(cfg, s)
+	want = []string{"-d", "Ubuntu", "--", "bash", "-lc", "screen -x '42.main'"}
+	if err != nil || name != "wsl" || !reflect.DeepEqual(args, want) {
+		t.Errorf("wsl: got %s %q, %v", name, args, err)
+	}
+
+	if _, _, err := attachArgs(cfg, monitor.ProjectStatus{Tty: "/dev/pts/1"}); err == nil {
+		t.Error("no tmux/screen: want error")
+	}
+}
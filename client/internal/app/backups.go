@@ -0,0 +1,100 @@
+//go:build windows
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"claude-status/internal/installer"
+	"claude-status/internal/logger"
+)
+
+// BackupsUsage backups 子命令的用法说明
+const BackupsUsage = `用法: claude-status backups <命令> [参数]
+
+命令:
+  list                      列出 settings 文件的备份（含时长与大小）
+  diff <name>               显示备份与当前 settings 文件的差异
+  restore <name>            将备份原子地恢复为对应的 settings 文件
+  prune [--keep N] [--max-age 30d] [--dry-run]
+                            按保留策略清理备份，满足任一条件的备份会保留
+
+<name> 可以是 list 输出的完整路径，也可以是唯一的文件名`
+
+// RunBackups 管理服务端 settings 文件的备份（SSH 与 WSL 通用），
+// 管理范围为安装时记录的 Hook 目标文件。args 为 backups 之后的子命令及参数，结果写入 w。
+func RunBackups(configPath string, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("缺少子命令\n\n%s", BackupsUsage)
+	}
+
+	if err := logger.Init(); err == nil {
+		defer logger.Close()
+	}
+	logger.Info("RunBackups: configPath=%s args=%v", configPath, args)
+
+	// 先解析参数，避免参数错误时还要建立连接
+	var scriptArgs []string
+	switch sub := args[0]; sub {
+	case "list":
+		scriptArgs = []string{"list"}
+	case "diff", "restore":
+		if len(args) != 2 {
+			return fmt.Errorf("用法: claude-status backups %s <name>", sub)
+		}
+		scriptArgs = []string{sub, args[1]}
+	case "prune":
+		pruneArgs, err := parsePruneArgs(args[1:])
+		if err != nil {
+			return err
+		}
+		scriptArgs = append([]string{"prune"}, pruneArgs...)
+	default:
+		return fmt.Errorf("未知子命令: %s\n\n%s", sub, BackupsUsage)
+	}
+
+	inst, err := connectInstaller(configPath)
+	if err != nil {
+		return err
+	}
+	defer inst.Close()
+
+	output, err := inst.RunScript(installer.BackupsScript, scriptArgs...)
+	if err != nil {
+		return fmt.Errorf("%w (output: %s)", err, strings.TrimSpace(output))
+	}
+
+	if args[0] == "list" {
+		return printBackupList(w, output, time.Now())
+	}
+
+	fmt.Fprint(w, output)
+	return nil
+}
+
+// printBackupList 以表格形式输出备份列表
+func printBackupList(w io.Writer, output string, now time.Time) error {
+	entries, err := parseBackupList(output)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "没有 settings 备份")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tAGE\tSIZE")
+	var total int64
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", e.Name, formatAge(now.Sub(e.ModTime)), formatSize(e.Size))
+		total += e.Size
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\n共 %d 个备份，%s\n", len(entries), formatSize(total))
+	return nil
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BackupEntry 服务端 settings.json 的一个备份文件
+type BackupEntry struct {
+	Name    string
+	ModTime time.Time
+	Size    int64
+}
+
+// parseBackupList 解析 backups.sh list 的输出（每行 名称\t修改时间戳\t字节数）
+func parseBackupList(output string) ([]BackupEntry, error) {
+	var entries []BackupEntry
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("无法解析备份列表: %q", line)
+		}
+		mtime, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无法解析修改时间: %q", line)
+		}
+		size, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无法解析文件大小: %q", line)
+		}
+		entries = append(entries, BackupEntry{
+			Name:    fields[0],
+			ModTime: time.Unix(mtime, 0),
+			Size:    size,
+		})
+	}
+	return entries, nil
+}
+
+// parseAge 解析保留时长，在 time.ParseDuration 的基础上支持 d（天）后缀，
+// 例如 "30d"、"12h"、"90m"
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("无效的时长: %s", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("无效的时长: %s", s)
+	}
+	return d, nil
+}
+
+// parsePruneArgs 解析 prune 参数并转换为 backups.sh 所需的形式（时长转为秒）
+func parsePruneArgs(args []string) ([]string, error) {
+	fs := flag.NewFlagSet("prune", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	keep := fs.Int("keep", -1, "保留最新的 N 个备份")
+	maxAge := fs.String("max-age", "", "保留该时长以内的备份，如 30d、12h")
+	dryRun := fs.Bool("dry-run", false, "只显示将要删除的备份")
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("解析 prune 参数失败: %w", err)
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("prune 不接受多余的参数: %s", strings.Join(fs.Args(), " "))
+	}
+
+	if *keep < 0 && *maxAge == "" {
+		return nil, fmt.Errorf("prune 需要 --keep 和/或 --max-age")
+	}
+
+	var out []string
+	if *keep >= 0 {
+		out = append(out, "--keep", strconv.Itoa(*keep))
+	}
+	if *maxAge != "" {
+		d, err := parseAge(*maxAge)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, "--max-age", strconv.FormatInt(int64(d.Seconds()), 10))
+	}
+	if *dryRun {
+		out = append(out, "--dry-run")
+	}
+	return out, nil
+}
+
+// formatAge 将时长格式化为紧凑的可读形式，例如 "3d"、"5h"、"12m"
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// formatSize 将字节数格式化为可读形式
+func formatSize(n int64) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%d B", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	}
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBackupList(t *testing.T) {
+	output := "settings.json.backup.20260102000000\t1767312000\t812\n" +
+		"settings.json.backup.uninstall.20260101000000\t1767225600\t2048\n\n"
+
+	entries, err := parseBackupList(output)
+	if err != nil {
+		t.Fatalf("parseBackupList() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries[0].Name != "settings.json.backup.20260102000000" {
+		t.Errorf("entries[0].Name = %q", entries[0].Name)
+	}
+	if entries[1].Size != 2048 {
+		t.Errorf("entries[1].Size = %d, want 2048", entries[1].Size)
+	}
+	if entries[1].ModTime.Unix() != 1767225600 {
+		t.Errorf("entries[1].ModTime = %v", entries[1].ModTime)
+	}
+
+	if _, err := parseBackupList("garbage line"); err == nil {
+		t.Error("parseBackupList(garbage) should fail")
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"30d", 30 * 24 * time.Hour, false},
+		{"12h", 12 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"-1d", 0, true},
+		{"abc", 0, true},
+		{"d", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseAge(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseAge(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseAge(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParsePruneArgs(t *testing.T) {
+	got, err := parsePruneArgs([]string{"--keep", "3", "--max-age", "1h", "--dry-run"})
+	if err != nil {
+		t.Fatalf("parsePruneArgs() error = %v", err)
+	}
+	if strings.Join(got, " ") != "--keep 3 --max-age 3600 --dry-run" {
+		t.Errorf("parsePruneArgs() = %v", got)
+	}
+
+	for _, args := range [][]string{
+		{},
+		{"--keep"},
+		{"--keep", "3", "oops", "--dry-run"},
+	} {
+		if _, err := parsePruneArgs(args); err == nil {
+			t.Errorf("parsePruneArgs(%q) should fail", args)
+		}
+	}
+}
+
+func TestFormatAgeAndSize(t *testing.T) {
+	ages := []struct {
+		d    time.Duration
+		want string
+	}{
+		{30 * time.Second, "30s"},
+		{12 * time.Minute, "12m"},
+		{5 * time.Hour, "5h"},
+		{72 * time.Hour, "3d"},
+	}
+	for _, tt := range ages {
+		if got := formatAge(tt.d); got != tt.want {
+			t.Errorf("formatAge(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+
+	sizes := []struct {
+		n    int64
+		want string
+	}{
+		{812, "812 B"},
+		{2048, "2.0 KB"},
+		{3 * 1024 * 1024, "3.0 MB"},
+	}
+	for _, tt := range sizes {
+		if got := formatSize(tt.n); got != tt.want {
+			t.Errorf("formatSize(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
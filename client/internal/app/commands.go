@@ -0,0 +1,52 @@
+//go:build windows
+
+package app
+
+import (
+	"fmt"
+
+	"claude-status/internal/config"
+	"claude-status/internal/installer"
+	"claude-status/internal/monitor"
+	"claude-status/internal/wsl"
+)
+
+// newInstaller 根据配置创建 SSH 或 WSL 安装器
+func newInstaller(cfg *config.Config) monitor.Installer {
+	if cfg.WSL.Enabled {
+		return wsl.NewInstaller(cfg)
+	}
+	return installer.NewInstaller(cfg)
+}
+
+// loadCommandConfig 为命令行子命令加载配置文件
+func loadCommandConfig(configPath string) (*config.Config, error) {
+	if !config.Exists(configPath) {
+		return nil, fmt.Errorf("配置文件不存在: %s", configPath)
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+	return cfg, nil
+}
+
+// connectInstaller 为命令行子命令加载配置并连接服务端，
+// 调用方负责在使用完毕后 Close。
+func connectInstaller(configPath string) (monitor.Installer, error) {
+	cfg, err := loadCommandConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	return connectConfigInstaller(cfg)
+}
+
+// connectConfigInstaller 使用已加载的配置连接服务端，调用方负责 Close
+func connectConfigInstaller(cfg *config.Config) (monitor.Installer, error) {
+	inst := newInstaller(cfg)
+	if err := inst.Connect(); err != nil {
+		return nil, fmt.Errorf("连接失败: %w", err)
+	}
+	return inst, nil
+}
@@ -0,0 +1,128 @@
+//go:build windows
+
+package app
+
+import (
+	"strconv"
+	"time"
+
+	"claude-status/internal/config"
+	"claude-status/internal/logger"
+	"claude-status/internal/monitor"
+)
+
+// pingInterval 测量监控连接往返延迟的间隔
+const pingInterval = 30 * time.Second
+
+// skewReportStep 时钟偏差的变化超过该值才更新 UI，避免平滑过程中频繁刷新
+const skewReportStep = time.Second
+
+// leaseDuration 客户端租约时长，按 pingInterval 续租；连接断开后最迟在该时长后服务端开始推送通知
+const leaseDuration = 3 * pingInterval
+
+// applyMonitorSettings 连接建立后把 monitor 配置（项目过滤、保留策略、合并窗口）发送给服务端。
+// 保留策略由服务端保存并定期执行，未配置时也发送默认值，使服务端与当前配置一致
+func applyMonitorSettings(client monitor.Client, ui UI, m config.MonitorConfig) {
+	if len(m.Projects) > 0 {
+		if _, err := client.Request(monitor.CmdSubscribe, m.Projects...); err != nil {
+			logger.Error("设置项目过滤失败: %v", err)
+			ui.Notify("设置项目过滤失败", err.Error())
+		}
+	}
+	archive := "0"
+	if m.Archive {
+		archive = "1"
+	}
+	if _, err := client.Request(monitor.CmdSetRetention,
+		strconv.Itoa(m.GetRetention()*60), strconv.Itoa(m.GetStoppedRetention()*60), archive); err != nil {
+		logger.Error("设置保留策略失败: %v", err)
+		ui.Notify("设置保留策略失败", err.Error())
+	}
+	if _, err := client.Request(monitor.CmdSetDebounce, strconv.Itoa(m.GetDebounce())); err != nil {
+		logger.Error("设置合并窗口失败: %v", err)
+	}
+}
+
+// measureLatency 发送 ping 并在悬浮窗显示往返延迟，失败时隐藏
+func measureLatency(client monitor.Client, ui UI) {
+	rtt, err := monitor.Ping(client)
+	if err != nil {
+		logger.Error("ping 失败: %v", err)
+		ui.SetLatency(0)
+		return
+	}
+	logger.Debug("ping: %s", rtt)
+	ui.SetLatency(rtt)
+}
+
+// renewLease 续租：租约有效期间服务端不推送通知（server_notify.always 除外）
+func renewLease(client monitor.Client) {
+	if _, err := client.Request(monitor.CmdLease, strconv.Itoa(int(leaseDuration/time.Second))); err != nil {
+		logger.Error("续租失败: %v", err)
+	}
+}
+
+// reportClockSkew 偏差明显变化时更新悬浮窗与 tooltip，返回新的已报告值
+func reportClockSkew(ui UI, reported, skew time.Duration) time.Duration {
+	if d := skew - reported; d > -skewReportStep && d < skewReportStep {
+		return reported
+	}
+	if monitor.SkewExceeded(skew) {
+		logger.Info("时钟偏差: %s", monitor.SkewLabel(skew))
+	}
+	ui.SetClockSkew(skew)
+	return skew
+}
+
+// resyncStatus 请求服务端重新扫描并推送完整状态
+func resyncStatus(client monitor.Client, ui UI) {
+	if _, err := client.Request(monitor.CmdResync); err != nil {
+		logger.Error("刷新状态失败: %v", err)
+		ui.Notify("刷新状态失败", err.Error())
+	}
+}
+
+// replayResult replay 命令的结果：补发完成后的事件日志位置
+type replayResult struct {
+	pos monitor.JournalPosition
+	err error
+}
+
+// requestReplay 请求补发事件日志中 from 之后的事件（经 EventChan 先于回复到达），
+// from 为零值（首次连接）时只取得当前位置
+func requestReplay(client monitor.Client, from monitor.JournalPosition, done chan<- replayResult) {
+	var args []string
+	if from.Journal != "" {
+		args = []string{from.Journal, strconv.FormatInt(from.Seq, 10)}
+	}
+	msg, err := client.Request(monitor.CmdReplay, args...)
+	if err != nil {
+		done <- replayResult{err: err}
+		return
+	}
+	pos, err := monitor.ParseJournalPosition(msg)
+	done <- replayResult{pos: pos, err: err}
+}
+
+// reportAway 显示离线汇总，配置了 notify.replay 时为需要处理的会话补发通知
+func reportAway(ui UI, notifiers []Notifier, cfg *config.Config, from monitor.JournalPosition, events []monitor.JournalEvent) {
+	if len(events) == 0 {
+		return
+	}
+	now := time.Now()
+	title, text := awaySummary(from, events, now)
+	logger.Info("%s: %s", title, text)
+	ui.Notify(title, text)
+	if cfg.Notify.Replay {
+		forwardNotifications(notifiers, awayNotifications(events, now))
+	}
+}
+
+// removeSession 请求服务端删除会话的状态文件，会话再次触发 Hook 时重新出现
+func removeSession(client monitor.Client, ui UI, s monitor.ProjectStatus) {
+	logger.Info("removeSession: session=%s", s.SessionId)
+	if _, err := client.Request(monitor.CmdDeleteSession, s.SessionId); err != nil {
+		logger.Error("移除会话失败: %v", err)
+		ui.Notify("移除会话失败", err.Error())
+	}
+}
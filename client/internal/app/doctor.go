@@ -0,0 +1,124 @@
+//go:build windows
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"claude-status/internal/config"
+	"claude-status/internal/installer"
+	"claude-status/internal/logger"
+	"claude-status/internal/monitor"
+	"claude-status/internal/version"
+)
+
+// RunDoctor 输出客户端配置与服务端安装情况的诊断报告，
+// 包括当前配置的 Hook 安装范围以及服务端实际生效的范围。
+func RunDoctor(configPath string, w io.Writer) error {
+	if err := logger.Init(); err == nil {
+		defer logger.Close()
+	}
+	logger.Info("RunDoctor: configPath=%s", configPath)
+
+	cfg, err := loadCommandConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "== 客户端 ==")
+	fmt.Fprintf(w, "客户端版本: %s\n", version.Version)
+	fmt.Fprintf(w, "配置文件: %s\n", configPath)
+	fmt.Fprintf(w, "连接方式: %s (%s)\n", getMode(cfg), getDisplayName(cfg))
+	if !cfg.WSL.Enabled {
+		if cfg.Server.MonitorIdentityFile != "" {
+			fmt.Fprintf(w, "监控密钥: %s（受限，仅运行 monitor.sh）\n", cfg.GetMonitorIdentityFile())
+		} else {
+			fmt.Fprintln(w, "监控密钥: 与安装共用常规密钥（可运行 provision 生成受限密钥）")
+		}
+	}
+	if n := cfg.ServerNotify; n.Enabled() {
+		var methods []string
+		if n.Webhook != "" {
+			methods = append(methods, "webhook")
+		}
+		if n.SMTP.URL != "" {
+			methods = append(methods, "smtp")
+		}
+		if n.Command != "" {
+			methods = append(methods, "command")
+		}
+		fmt.Fprintf(w, "服务端通知: %s (events: %s，安装时推送到服务端)\n", strings.Join(methods, ", "), strings.Join(n.GetEvents(), ","))
+	}
+	archive := "删除"
+	if cfg.Monitor.Archive {
+		archive = "归档"
+	}
+	fmt.Fprintf(w, "保留策略: 未结束会话 %d 分钟，已结束会话 %d 分钟，过期后%s（连接时推送到服务端）\n",
+		cfg.Monitor.GetRetention(), cfg.Monitor.GetStoppedRetention(), archive)
+	fmt.Fprintf(w, "配置的安装模式: %s\n", cfg.Install.GetMode())
+	fmt.Fprintf(w, "配置的安装范围: %s\n", cfg.Install.GetScope())
+	if cfg.Install.GetMode() == config.ModePlugin {
+		for _, d := range cfg.Install.PluginDirs() {
+			fmt.Fprintf(w, "插件目录: %s\n", d)
+		}
+	} else {
+		targets := cfg.Install.SettingsTargets()
+		if len(targets) == 0 {
+			fmt.Fprintln(w, "目标文件: ${CLAUDE_CONFIG_DIR:-~/.claude}/settings.json")
+		}
+		for _, t := range targets {
+			fmt.Fprintf(w, "目标文件: %s\n", t)
+		}
+	}
+	fmt.Fprintln(w)
+
+	inst := newInstaller(cfg)
+	if err := inst.Connect(); err != nil {
+		return fmt.Errorf("连接失败: %w", err)
+	}
+	defer inst.Close()
+
+	output, err := inst.RunScript(installer.DoctorScript)
+	if err != nil {
+		return fmt.Errorf("%w (output: %s)", err, strings.TrimSpace(output))
+	}
+	fmt.Fprint(w, output)
+
+	if skew, err := measureClockSkew(inst); err != nil {
+		fmt.Fprintf(w, "时钟偏差: 无法测量 (%v)\n", err)
+	} else if monitor.SkewExceeded(skew) {
+		fmt.Fprintf(w, "时钟偏差: %s [警告] 超过 %s，客户端已按服务端时间校正状态时长，建议在服务端或本机启用 NTP 校时\n",
+			monitor.SkewLabel(skew), monitor.FormatDuration(int64(monitor.SkewWarnThreshold/time.Second)))
+	} else {
+		fmt.Fprintf(w, "时钟偏差: %s\n", monitor.SkewLabel(skew))
+	}
+
+	// 服务端通知依赖守护进程，缺少其依赖时通知不会发送，以失败退出
+	if cfg.ServerNotify.Enabled() {
+		if ok, msg := inst.CheckDependencies(); !ok {
+			return fmt.Errorf("服务端通知不可用: %s", msg)
+		}
+	}
+	return nil
+}
+
+// measureClockSkew 读取服务端当前时间，以往返的中点作为对应的本机时间估计时钟偏差（服务端 - 客户端）
+func measureClockSkew(inst monitor.Installer) (time.Duration, error) {
+	start := time.Now()
+	output, err := inst.RunScript("date +%s")
+	if err != nil {
+		return 0, err
+	}
+	end := time.Now()
+	serverNow, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("无法解析服务端时间: %q", strings.TrimSpace(output))
+	}
+	var clock monitor.ClockSkew
+	clock.Observe(serverNow, start.Add(end.Sub(start)/2))
+	return clock.Offset(), nil
+}
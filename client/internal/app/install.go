@@ -0,0 +1,45 @@
+//go:build windows
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"claude-status/internal/logger"
+)
+
+// RunInstall 重新执行服务端安装：上传脚本与按配置生成的文件（如服务端通知配置），并配置 Hook。
+// 修改 server_notify、install 等配置后用于推送到服务端，无需等待版本升级
+func RunInstall(configPath string, w io.Writer) error {
+	if err := logger.Init(); err == nil {
+		defer logger.Close()
+	}
+	logger.Info("RunInstall: configPath=%s", configPath)
+
+	cfg, err := loadCommandConfig(configPath)
+	if err != nil {
+		return err
+	}
+	inst, err := connectConfigInstaller(cfg)
+	if err != nil {
+		return err
+	}
+	defer inst.Close()
+
+	if ok, msg := inst.CheckDependencies(); !ok {
+		return errors.New(msg)
+	}
+	if err := inst.Install(); err != nil {
+		return fmt.Errorf("安装失败: %w", err)
+	}
+
+	fmt.Fprintf(w, "服务端安装完成 (%s)\n", getDisplayName(cfg))
+	if cfg.ServerNotify.Enabled() {
+		fmt.Fprintln(w, "服务端通知配置已更新")
+	} else {
+		fmt.Fprintln(w, "服务端通知未配置（已关闭）")
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"claude-status/internal/config"
+	"claude-status/internal/monitor"
+)
+
+// journalFile 数据目录下保存各服务器事件日志位置的文件
+const journalFile = "journal.json"
+
+// awaySummaryLimit 离线汇总最多列出的会话数
+const awaySummaryLimit = 5
+
+// journalKey 区分服务器的键（同一服务器上的事件日志位置共用）
+func journalKey(cfg *config.Config) string {
+	if cfg.WSL.Enabled {
+		return "wsl:" + cfg.WSL.Distro
+	}
+	return fmt.Sprintf("%s@%s:%d", cfg.Server.User, cfg.Server.Host, cfg.Server.Port)
+}
+
+// loadJournalPositions 读取各服务器的事件日志位置，文件不存在或损坏时返回空表
+func loadJournalPositions() map[string]monitor.JournalPosition {
+	positions := make(map[string]monitor.JournalPosition)
+	dir, err := config.DataDir()
+	if err != nil {
+		return positions
+	}
+	data, err := os.ReadFile(filepath.Join(dir, journalFile))
+	if err == nil {
+		json.Unmarshal(data, &positions)
+	}
+	return positions
+}
+
+// loadJournalPosition 读取服务器的事件日志位置，没有记录时为零值（首次连接不补发）
+func loadJournalPosition(key string) monitor.JournalPosition {
+	return loadJournalPositions()[key]
+}
+
+// saveJournalPosition 保存服务器的事件日志位置
+func saveJournalPosition(key string, pos monitor.JournalPosition) error {
+	dir, err := config.DataDir()
+	if err != nil {
+		return err
+	}
+	positions := loadJournalPositions()
+	positions[key] = pos
+	data, err := json.MarshalIndent(positions, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, journalFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, journalFile))
+}
+
+// awaySession 离线期间单个会话的变化
+type awaySession struct {
+	last    monitor.JournalEvent // 最后一次变化
+	changes int
+}
+
+// groupAway 按会话汇总补发的事件，按最后变化时间从新到旧排序
+func groupAway(events []monitor.JournalEvent) []awaySession {
+	index := make(map[string]int)
+	var sessions []awaySession
+	for _, e := range events {
+		i, ok := index[e.SessionId]
+		if !ok {
+			i = len(sessions)
+			index[e.SessionId] = i
+			sessions = append(sessions, awaySession{})
+		}
+		sessions[i].last = e
+		sessions[i].changes++
+	}
+	sort.SliceStable(sessions, func(a, b int) bool {
+		return sessions[a].last.Seq > sessions[b].last.Seq
+	})
+	return sessions
+}
+
+// formatEventTime 当天的事件只显示时分，否则带上日期
+func formatEventTime(ts int64, now time.Time) string {
+	t := time.Unix(ts, 0).In(now.Location())
+	if t.Year() == now.Year() && t.YearDay() == now.YearDay() {
+		return t.Format("15:04")
+	}
+	return t.Format("01-02 15:04")
+}
+
+// awaySummary 离线汇总：列出期间有变化的会话及其最终状态。from 为补发前的位置，
+// 第一条补发事件与其不连续时说明更早的事件已被服务端轮转丢弃
+func awaySummary(from monitor.JournalPosition, events []monitor.JournalEvent, now time.Time) (title, text string) {
+	sessions := groupAway(events)
+	title = fmt.Sprintf("离线期间 %d 个会话有变化", len(sessions))
+
+	var lines []string
+	for i, s := range sessions {
+		if i == awaySummaryLimit {
+			lines = append(lines, fmt.Sprintf("另有 %d 个会话", len(sessions)-i))
+			break
+		}
+		name := s.last.ProjectName
+		if name == "" {
+			name = s.last.SessionId
+		}
+		lines = append(lines, fmt.Sprintf("%s · %s（%d 次变化，%s）",
+			name, monitor.StatusLabel(s.last.To), s.changes, formatEventTime(s.last.Time, now)))
+	}
+	if len(events) > 0 && events[0].Journal == from.Journal && events[0].Seq > from.Seq+1 {
+		lines = append(lines, "更早的变化已超出服务端日志的保留范围")
+	}
+	return title, strings.Join(lines, "\n")
+}
+
+// awayNotifications 为离线期间最终等待输入或授权的会话生成补发通知
+func awayNotifications(events []monitor.JournalEvent, now time.Time) []Notification {
+	var result []Notification
+	for _, s := range groupAway(events) {
+		if s.last.To != monitor.StatusIdle && s.last.To != monitor.StatusPermission {
+			continue
+		}
+		result = append(result, Notification{
+			SessionId:   s.last.SessionId,
+			Project:     s.last.Project,
+			ProjectName: s.last.ProjectName,
+			Type:        "away",
+			Message: fmt.Sprintf("离线期间（%s）变为%s",
+				formatEventTime(s.last.Time, now), monitor.StatusLabel(s.last.To)),
+		})
+	}
+	return result
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"claude-status/internal/monitor"
+)
+
+func TestAwaySummary(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	ev := func(seq int64, id, name, from, to string) monitor.JournalEvent {
+		return monitor.JournalEvent{Seq: seq, Time: now.Unix(), SessionId: id, ProjectName: name,
+			From: from, To: to, Journal: "j1", Replay: true}
+	}
+	events := []monitor.JournalEvent{
+		ev(4, "a", "alpha", "working", "idle"),
+		ev(5, "b", "beta", "idle", "working"),
+		ev(6, "a", "alpha", "idle", "working"),
+		ev(7, "a", "alpha", "working", "permission"),
+	}
+
+	title, text := awaySummary(monitor.JournalPosition{Journal: "j1", Seq: 3}, events, now)
+	if title != "离线期间 2 个会话有变化" {
+		t.Errorf("title = %q", title)
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "alpha · 等待授权（3 次变化") || !strings.HasPrefix(lines[1], "beta · 运行中（1 次变化") {
+		t.Errorf("text = %q, want alpha (latest) before beta", text)
+	}
+
+	// 与上次位置不连续：更早的事件已被轮转
+	if _, text := awaySummary(monitor.JournalPosition{Journal: "j1", Seq: 1}, events, now); !strings.Contains(text, "保留范围") {
+		t.Errorf("gap not reported: %q", text)
+	}
+	// 日志已重建时不提示
+	if _, text := awaySummary(monitor.JournalPosition{Journal: "j0", Seq: 1}, events, now); strings.Contains(text, "保留范围") {
+		t.Errorf("gap reported for a different journal: %q", text)
+	}
+
+	got := awayNotifications(events, now)
+	if len(got) != 1 || got[0].SessionId != "a" || got[0].Type != "away" {
+		t.Errorf("notifications = %+v, want single away notification for a", got)
+	}
+}
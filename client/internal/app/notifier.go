@@ -0,0 +1,81 @@
+//go:build windows
+
+package app
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"claude-status/internal/config"
+	"claude-status/internal/logger"
+)
+
+// Notifier 通知器，负责把会话通知推送给用户
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// newNotifiers 根据配置创建通知器列表，未配置时返回空列表
+func newNotifiers(cfg *config.Config, ui UI) []Notifier {
+	var notifiers []Notifier
+	if cfg.Notify.Balloon {
+		notifiers = append(notifiers, &balloonNotifier{ui: ui})
+	}
+	if cfg.Notify.Command != "" {
+		notifiers = append(notifiers, &commandNotifier{command: cfg.Notify.Command})
+	}
+	return notifiers
+}
+
+// forwardNotifications 将通知逐个转发给所有通知器，失败只记录日志
+func forwardNotifications(notifiers []Notifier, notifications []Notification) {
+	for _, n := range notifications {
+		logger.Info("转发通知: session=%s type=%s message=%s", n.SessionId, n.Type, n.Message)
+		for _, notifier := range notifiers {
+			if err := notifier.Notify(n); err != nil {
+				logger.Error("通知发送失败: %v", err)
+			}
+		}
+	}
+}
+
+// balloonNotifier 托盘气泡通知
+type balloonNotifier struct {
+	ui UI
+}
+
+func (b *balloonNotifier) Notify(n Notification) error {
+	title := n.ProjectName
+	if n.Branch != "" {
+		title += " · " + n.Branch
+	}
+	b.ui.Notify(title, n.Message)
+	return nil
+}
+
+// commandNotifier 执行本地命令，通知内容通过环境变量传入：
+// CLAUDE_STATUS_PROJECT、CLAUDE_STATUS_PROJECT_NAME、CLAUDE_STATUS_BRANCH、CLAUDE_STATUS_SESSION、
+// CLAUDE_STATUS_TYPE、CLAUDE_STATUS_MESSAGE
+type commandNotifier struct {
+	command string
+}
+
+func (c *commandNotifier) Notify(n Notification) error {
+	cmd := exec.Command("cmd", "/C", c.command)
+	cmd.Env = append(os.Environ(),
+		"CLAUDE_STATUS_PROJECT="+n.Project,
+		"CLAUDE_STATUS_PROJECT_NAME="+n.ProjectName,
+		"CLAUDE_STATUS_BRANCH="+n.Branch,
+		"CLAUDE_STATUS_SESSION="+n.SessionId,
+		"CLAUDE_STATUS_TYPE="+n.Type,
+		"CLAUDE_STATUS_MESSAGE="+n.Message,
+	)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// 异步回收子进程，不阻塞状态更新
+	go cmd.Wait()
+	return nil
+}
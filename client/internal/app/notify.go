@@ -0,0 +1,55 @@
+package app
+
+import (
+	"claude-status/internal/monitor"
+)
+
+// Notification 需要转发给通知器的会话通知
+type Notification struct {
+	SessionId   string
+	Project     string
+	ProjectName string
+	Branch      string // Git 分支（有未提交修改时带 "*"），区分同一仓库的多个 worktree
+	Type        string // Notification Hook 的类型，如 permission_prompt、idle_prompt；客户端产生的提醒为 budget、usage_limit、away
+	Message     string
+}
+
+// notificationTracker 记录每个会话最近一次转发的通知，避免同一通知重复转发
+type notificationTracker struct {
+	seen   map[string]string // session_id -> type + message
+	primed bool
+}
+
+// newNotificationTracker 创建通知跟踪器
+func newNotificationTracker() *notificationTracker {
+	return &notificationTracker{seen: make(map[string]string)}
+}
+
+// Changed 返回本次状态中新出现或内容变化的通知。
+// 首次调用只记录不返回，避免重连时把已有的旧通知再推送一遍。
+func (t *notificationTracker) Changed(statuses []monitor.ProjectStatus) []Notification {
+	var result []Notification
+	current := make(map[string]string, len(statuses))
+
+	for _, s := range statuses {
+		if s.NotificationMessage == "" || s.Status == monitor.StatusStopped {
+			continue
+		}
+		key := s.NotificationType + "\x00" + s.NotificationMessage
+		current[s.SessionId] = key
+		if t.primed && t.seen[s.SessionId] != key {
+			result = append(result, Notification{
+				SessionId:   s.SessionId,
+				Project:     s.Project,
+				ProjectName: s.ProjectName,
+				Branch:      s.BranchLabel(),
+				Type:        s.NotificationType,
+				Message:     s.NotificationMessage,
+			})
+		}
+	}
+
+	t.seen = current
+	t.primed = true
+	return result
+}
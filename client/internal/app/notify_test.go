@@ -0,0 +1,32 @@
+package app
+
+import (
+	"testing"
+
+	"claude-status/internal/monitor"
+)
+
+func TestNotificationTrackerChanged(t *testing.T) {
+	tr := newNotificationTracker()
+	st := func(id, msg string) monitor.ProjectStatus {
+		return monitor.ProjectStatus{SessionId: id, Status: "idle", NotificationMessage: msg, NotificationType: "idle_prompt"}
+	}
+
+	// 首次调用只记录
+	if got := tr.Changed([]monitor.ProjectStatus{st("a", "old")}); len(got) != 0 {
+		t.Fatalf("first call: got %d notifications, want 0", len(got))
+	}
+
+	// 相同通知不重复转发，新会话的通知需要转发
+	got := tr.Changed([]monitor.ProjectStatus{st("a", "old"), st("b", "waiting")})
+	if len(got) != 1 || got[0].SessionId != "b" || got[0].Message != "waiting" {
+		t.Fatalf("got %+v, want single notification for b", got)
+	}
+
+	// 通知清除后再次出现相同内容，需要重新转发
+	tr.Changed([]monitor.ProjectStatus{st("a", ""), st("b", "waiting")})
+	got = tr.Changed([]monitor.ProjectStatus{st("a", "old"), st("b", "waiting")})
+	if len(got) != 1 || got[0].SessionId != "a" {
+		t.Fatalf("got %+v, want single notification for a", got)
+	}
+}
@@ -0,0 +1,111 @@
+//go:build windows
+
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"claude-status/internal/config"
+	"claude-status/internal/installer"
+	"claude-status/internal/logger"
+	"claude-status/internal/monitor"
+	"claude-status/internal/ssh"
+)
+
+// ProvisionUsage provision 子命令的用法说明
+const ProvisionUsage = `用法: claude-status provision [--revoke]
+
+生成监控专用的 ed25519 密钥，并使用常规密钥将其写入服务端 authorized_keys，
+限制为只能运行 ~/.claude-status/monitor.sh（no-pty、禁止转发）。
+完成后配置中的 server.monitor_identity_file 指向该密钥，托盘的监控连接改用它；
+安装与升级仍使用常规密钥。
+
+  --revoke    从服务端移除监控密钥，删除本地密钥并恢复使用常规密钥监控`
+
+// RunProvision 生成并授权受限的监控密钥（仅 SSH 模式），结果写入 w
+func RunProvision(configPath string, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("provision", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	revoke := fs.Bool("revoke", false, "")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%v\n\n%s", err, ProvisionUsage)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("多余的参数: %s\n\n%s", strings.Join(fs.Args(), " "), ProvisionUsage)
+	}
+
+	if err := logger.Init(); err == nil {
+		defer logger.Close()
+	}
+	logger.Info("RunProvision: configPath=%s revoke=%v", configPath, *revoke)
+
+	cfg, err := loadCommandConfig(configPath)
+	if err != nil {
+		return err
+	}
+	if cfg.WSL.Enabled {
+		return fmt.Errorf("WSL 模式不通过 SSH 连接，无需 provision")
+	}
+
+	inst := newInstaller(cfg)
+	if err := inst.Connect(); err != nil {
+		return fmt.Errorf("连接失败: %w", err)
+	}
+	defer inst.Close()
+
+	if *revoke {
+		return revokeMonitorKey(inst, cfg, configPath, w)
+	}
+
+	dir, err := config.DataDir()
+	if err != nil {
+		return err
+	}
+	keyPath, publicKey, err := ssh.EnsureMonitorKey(dir)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "监控密钥: %s\n", keyPath)
+
+	output, err := inst.RunScript(installer.ProvisionScript, "add", ssh.MonitorAuthorizedKeyLine(publicKey))
+	if err != nil {
+		return fmt.Errorf("%w (output: %s)", err, strings.TrimSpace(output))
+	}
+	fmt.Fprint(w, output)
+
+	cfg.Server.MonitorIdentityFile = keyPath
+	if err := config.Save(configPath, cfg); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "配置已更新: server.monitor_identity_file = %s\n", keyPath)
+	fmt.Fprintln(w, "重启托盘程序后，监控连接将使用受限密钥")
+	return nil
+}
+
+// revokeMonitorKey 从服务端移除监控密钥，并清除本地密钥与配置
+func revokeMonitorKey(inst monitor.Installer, cfg *config.Config, configPath string, w io.Writer) error {
+	output, err := inst.RunScript(installer.ProvisionScript, "remove")
+	if err != nil {
+		return fmt.Errorf("%w (output: %s)", err, strings.TrimSpace(output))
+	}
+	fmt.Fprint(w, output)
+
+	if keyPath := cfg.Server.MonitorIdentityFile; keyPath != "" {
+		// 只删除 provision 生成的密钥，用户手动指定的密钥保持不动
+		if dir, err := config.DataDir(); err == nil && keyPath == filepath.Join(dir, ssh.MonitorKeyName) {
+			os.Remove(keyPath)
+			os.Remove(keyPath + ".pub")
+		}
+		cfg.Server.MonitorIdentityFile = ""
+		if err := config.Save(configPath, cfg); err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "配置已更新: 监控连接恢复使用常规密钥")
+	}
+	return nil
+}
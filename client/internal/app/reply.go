@@ -0,0 +1,18 @@
+//go:build windows
+
+package app
+
+import (
+	"claude-status/internal/logger"
+	"claude-status/internal/monitor"
+)
+
+// sendReply 经监控连接的 reply 控制命令，由服务端 reply.sh 把回复发送到会话的 tmux 窗格；
+// 服务端会再次校验会话状态与窗格，失败时以气泡提示原因（受限监控密钥下同样可用）
+func sendReply(client monitor.Client, ui UI, req monitor.ReplyRequest) {
+	logger.Info("sendReply: session=%s pane=%s action=%s", req.SessionId, req.Pane, req.Action)
+	if _, err := client.Request(monitor.CmdReply, req.Args()...); err != nil {
+		logger.Error("sendReply: %v", err)
+		ui.Notify("回复失败", err.Error())
+	}
+}
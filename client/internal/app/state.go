@@ -47,19 +47,19 @@ type Event int
 
 const (
 	EventConfigLoaded    Event = iota // 配置文件加载成功
-	EventServerSelected              // 用户选择了服务器
-	EventConnectSuccess              // 连接成功，会话已启动
-	EventConnectFailed               // 连接失败
-	EventVersionMismatch             // 服务端版本不匹配
-	EventNotConfigured               // 服务端未安装
-	EventInstallSuccess              // 安装/更新完成
-	EventInstallFailed               // 安装/更新失败
-	EventSessionError                // 活动会话出错
-	EventSessionClosed               // 连接断开
-	EventStatusUpdate                // 状态数据更新
-	EventUserDisconnect              // 用户主动断开
-	EventUserQuit                    // 用户退出或收到系统信号
-	EventSwitchServer                // 用户切换服务器
+	EventServerSelected               // 用户选择了服务器
+	EventConnectSuccess               // 连接成功，会话已启动
+	EventConnectFailed                // 连接失败
+	EventVersionMismatch              // 服务端版本不匹配
+	EventNotConfigured                // 服务端未安装
+	EventInstallSuccess               // 安装/更新完成
+	EventInstallFailed                // 安装/更新失败
+	EventSessionError                 // 活动会话出错
+	EventSessionClosed                // 连接断开
+	EventStatusUpdate                 // 状态数据更新
+	EventUserDisconnect               // 用户主动断开
+	EventUserQuit                     // 用户退出或收到系统信号
+	EventSwitchServer                 // 用户切换服务器
 )
 
 // String 返回事件的可读名称
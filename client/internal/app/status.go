@@ -0,0 +1,229 @@
+//go:build windows
+
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"claude-status/internal/logger"
+	"claude-status/internal/monitor"
+	"claude-status/internal/version"
+)
+
+// StatusUsage status 子命令的用法说明
+const StatusUsage = `用法: claude-status status [--json]
+
+输出服务端当前所有会话的状态、停留时长、累计运行时长、Token 用量与预估费用，
+以及当前 5 小时用量窗口的用量与重置倒计时。
+
+  --json    以 JSON 对象输出：sessions 为会话数组（含 state_seconds、total_busy_seconds、
+            estimated_cost、project_estimated_cost 等计算字段），usage_block 为当前用量窗口
+            （含 seconds_to_reset，配置了 block_limit 时另含 percent；无活动窗口时省略）`
+
+// statusScript 以 --once 模式运行已安装的 monitor.sh，只输出一次状态
+const statusScript = `exec "$HOME/.claude-status/monitor.sh" --once`
+
+// sessionReport status --json 输出的单个会话，在 ProjectStatus 基础上附加计算字段
+type sessionReport struct {
+	monitor.ProjectStatus
+	StateSeconds     int64 `json:"state_seconds"`      // 在当前状态停留的秒数，-1 表示未知
+	TotalBusySeconds int64 `json:"total_busy_seconds"` // 累计运行秒数（含正在进行的一段）
+	TotalTokens      int64 `json:"total_tokens"`       // 所有模型的 Token 总数
+
+	// 同一项目所有会话的合计
+	ProjectTotalTokens   int64   `json:"project_total_tokens"`
+	ProjectEstimatedCost float64 `json:"project_estimated_cost"`
+}
+
+// blockReport status --json 输出的用量窗口，附加重置倒计时与已用百分比
+type blockReport struct {
+	monitor.UsageBlock
+	SecondsToReset int64 `json:"seconds_to_reset"`
+	Percent        *int  `json:"percent,omitempty"` // 未配置 block_limit 时省略
+}
+
+// statusReport status --json 的输出
+type statusReport struct {
+	Sessions   []sessionReport `json:"sessions"`
+	UsageBlock *blockReport    `json:"usage_block,omitempty"`
+}
+
+// RunStatus 查询服务端会话状态（SSH 与 WSL 通用），结果写入 w
+func RunStatus(configPath string, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	asJSON := fs.Bool("json", false, "")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%v\n\n%s", err, StatusUsage)
+	}
+
+	if err := logger.Init(); err == nil {
+		defer logger.Close()
+	}
+	logger.Info("RunStatus: configPath=%s json=%v", configPath, *asJSON)
+
+	cfg, err := loadCommandConfig(configPath)
+	if err != nil {
+		return err
+	}
+	inst, err := connectConfigInstaller(cfg)
+	if err != nil {
+		return err
+	}
+	defer inst.Close()
+
+	output, err := inst.RunScript(statusScript)
+	if err != nil {
+		return fmt.Errorf("%w (output: %s)", err, strings.TrimSpace(output))
+	}
+
+	statuses, block, serverVersion, serverNow, err := parseMonitorOnce(output)
+	if err != nil {
+		return err
+	}
+	if serverVersion != version.Version {
+		logger.Info("RunStatus: 服务端版本 %s 与客户端 %s 不一致", serverVersion, version.Version)
+	}
+
+	// 时长按服务端时间计算（旧版服务端不带时间时用本机时间）
+	now := time.Now().Unix()
+	if serverNow > 0 {
+		if skew := time.Duration(serverNow-now) * time.Second; monitor.SkewExceeded(skew) && !*asJSON {
+			fmt.Fprintf(w, "警告: 时钟偏差 %s，时长已按服务端时间计算\n\n", monitor.SkewLabel(skew))
+		}
+		now = serverNow
+	}
+	applyCosts(statuses, cfg.Usage)
+	totals := projectTotals(statuses)
+	reports := make([]sessionReport, 0, len(statuses))
+	for _, s := range statuses {
+		if s.Status == monitor.StatusStopped {
+			continue
+		}
+		reports = append(reports, sessionReport{
+			ProjectStatus:        s,
+			StateSeconds:         s.StateSeconds(now),
+			TotalBusySeconds:     s.TotalBusySeconds(now),
+			TotalTokens:          s.TotalTokens(),
+			ProjectTotalTokens:   totals[s.Project].Tokens,
+			ProjectEstimatedCost: totals[s.Project].Cost,
+		})
+	}
+
+	var blockRep *blockReport
+	if block != nil && block.SecondsToReset(now) > 0 {
+		blockRep = &blockReport{UsageBlock: *block, SecondsToReset: block.SecondsToReset(now)}
+		if p := block.Percent(cfg.Usage.BlockLimit); p >= 0 {
+			blockRep.Percent = &p
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(statusReport{Sessions: reports, UsageBlock: blockRep})
+	}
+	if blockRep != nil {
+		fmt.Fprintln(w, blockRep.Label(now, cfg.Usage.BlockLimit))
+		fmt.Fprintln(w)
+	}
+	return printStatusTable(w, reports, now)
+}
+
+// parseMonitorOnce 解析 monitor.sh --once 的输出，忽略混入的调试行；
+// 用量窗口在状态之前输出，无活动窗口时为 nil；serverNow 为状态消息携带的服务端时间（旧版服务端为 0）
+func parseMonitorOnce(output string) (statuses []monitor.ProjectStatus, block *monitor.UsageBlock, serverVersion string, serverNow int64, err error) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var msg monitor.StatusMessage
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			continue
+		}
+		switch msg.Type {
+		case monitor.MsgTypeVersion:
+			serverVersion = msg.Version
+		case monitor.MsgTypeUsageBlock:
+			block = msg.Block
+		case monitor.MsgTypeError:
+			return nil, nil, serverVersion, 0, fmt.Errorf("服务端错误: %s", msg.Message)
+		case monitor.MsgTypeStatus:
+			return msg.Data, block, serverVersion, msg.Now, nil
+		}
+	}
+	return nil, nil, serverVersion, 0, fmt.Errorf("未收到服务端状态，请确认已安装（output: %s）", strings.TrimSpace(output))
+}
+
+// printStatusTable 以表格形式输出会话状态
+func printStatusTable(w io.Writer, reports []sessionReport, now int64) error {
+	if len(reports) == 0 {
+		fmt.Fprintln(w, "无活动会话")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROJECT\tBRANCH\tSTATE\tBUSY\tSTARTED\tSESSION\tTOKENS\tCOST\tCOMPACTS\tSUBAGENTS\tACTIVITY")
+	for _, r := range reports {
+		state := r.StateLabel(now)
+		if state == "" {
+			state = r.Status
+		}
+		if r.Source == monitor.SourceTranscript {
+			state += " (transcript)"
+		}
+		started := "-"
+		if r.StartedAt > 0 {
+			started = monitor.FormatDuration(now-r.StartedAt) + " 前"
+		}
+		activity := r.ActivityLabel()
+		if activity != "" && r.ToolCount > 0 {
+			activity = fmt.Sprintf("%s (%d 次调用)", activity, r.ToolCount)
+		}
+		branch := r.BranchLabel()
+		if branch == "" {
+			branch = "-"
+		}
+		tokens, cost := "-", "-"
+		if r.TotalTokens > 0 {
+			tokens, cost = monitor.FormatTokens(r.TotalTokens), monitor.FormatCost(r.EstimatedCost)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\n", r.ProjectName, branch, state,
+			monitor.FormatDuration(r.TotalBusySeconds), started, r.SessionId, tokens, cost,
+			r.CompactCount, len(r.Subagents), activity)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	// 按项目汇总用量（同一项目只输出一次）
+	printed := make(map[string]bool)
+	var lines []string
+	for _, r := range reports {
+		if printed[r.Project] || r.ProjectTotalTokens == 0 {
+			continue
+		}
+		printed[r.Project] = true
+		lines = append(lines, fmt.Sprintf("  %s\t%s\t%s", r.ProjectName,
+			monitor.FormatTokens(r.ProjectTotalTokens), monitor.FormatCost(r.ProjectEstimatedCost)))
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w, "\n按项目汇总:")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, line := range lines {
+		fmt.Fprintln(tw, line)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"fmt"
+
+	"claude-status/internal/monitor"
+)
+
+// StatusSummary 活动会话的汇总，用于托盘图标与状态菜单项
+type StatusSummary struct {
+	Working    int    // 运行中的会话数
+	Permission int    // 等待授权的会话数
+	Compacting int    // 正在压缩上下文的会话数
+	Idle       int    // 等待输入的会话数
+	Icon       string // 托盘图标："running" | "input-needed"
+	Text       string // 状态菜单项文本
+}
+
+// activeStatuses 过滤掉 stopped 状态和超时的实例。
+// 记录了 pid 的会话由服务端检测进程存活，不按 updated_at 超时过滤，避免长时间等待输入的会话被隐藏。
+func activeStatuses(statuses []monitor.ProjectStatus, now, statusTimeout int64) []monitor.ProjectStatus {
+	filtered := make([]monitor.ProjectStatus, 0, len(statuses))
+	for _, s := range statuses {
+		if s.Status == monitor.StatusStopped {
+			continue
+		}
+		if statusTimeout > 0 && s.Pid == 0 && now-s.UpdatedAt > statusTimeout {
+			continue
+		}
+		filtered = append(filtered, s)
+	}
+	return filtered
+}
+
+// summarizeStatuses 汇总已过滤（不含 stopped 与超时）的会话状态。
+// 等待授权最需要及时处理：只要存在该状态，图标即显示为需要输入，并在文本中单独列出。
+// 压缩上下文属于会话仍在工作，图标按运行中处理，并在文本末尾注明。
+func summarizeStatuses(statuses []monitor.ProjectStatus) StatusSummary {
+	var sum StatusSummary
+	for _, s := range statuses {
+		switch s.Status {
+		case monitor.StatusWorking:
+			sum.Working++
+		case monitor.StatusPermission:
+			sum.Permission++
+		case monitor.StatusCompacting:
+			sum.Compacting++
+		default:
+			sum.Idle++
+		}
+	}
+
+	if sum.Working+sum.Compacting > 0 && sum.Permission == 0 {
+		sum.Icon = "running"
+	} else {
+		sum.Icon = "input-needed"
+	}
+
+	switch {
+	case len(statuses) == 0:
+		sum.Text = "已连接 - 无活动项目"
+	case sum.Permission > 0 && sum.Working > 0:
+		sum.Text = fmt.Sprintf("等待授权 (%d 个项目)，运行中 %d", sum.Permission, sum.Working)
+	case sum.Permission > 0:
+		sum.Text = fmt.Sprintf("等待授权 (%d 个项目)", sum.Permission)
+	case sum.Working > 0:
+		sum.Text = fmt.Sprintf("运行中 (%d 个项目)", sum.Working)
+	case sum.Compacting > 0:
+		sum.Text = fmt.Sprintf("压缩上下文 (%d 个项目)", sum.Compacting)
+	default:
+		sum.Text = fmt.Sprintf("等待输入 (%d 个项目)", len(statuses))
+	}
+	if sum.Compacting > 0 && (sum.Permission > 0 || sum.Working > 0) {
+		sum.Text += fmt.Sprintf("，压缩中 %d", sum.Compacting)
+	}
+	return sum
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"testing"
+
+	"claude-status/internal/monitor"
+)
+
+func TestSummarizeStatuses(t *testing.T) {
+	st := func(status string) monitor.ProjectStatus {
+		return monitor.ProjectStatus{Status: status}
+	}
+
+	tests := []struct {
+		name     string
+		statuses []monitor.ProjectStatus
+		icon     string
+		text     string
+	}{
+		{"empty", nil, "input-needed", "已连接 - 无活动项目"},
+		{"working", []monitor.ProjectStatus{st("working"), st("idle")}, "running", "运行中 (1 个项目)"},
+		{"idle", []monitor.ProjectStatus{st("idle"), st("idle")}, "input-needed", "等待输入 (2 个项目)"},
+		{"permission", []monitor.ProjectStatus{st("permission"), st("idle")}, "input-needed", "等待授权 (1 个项目)"},
+		{"permission and working", []monitor.ProjectStatus{st("permission"), st("working"), st("working")}, "input-needed", "等待授权 (1 个项目)，运行中 2"},
+		{"compacting", []monitor.ProjectStatus{st("compacting"), st("idle")}, "running", "压缩上下文 (1 个项目)"},
+		{"working and compacting", []monitor.ProjectStatus{st("working"), st("compacting")}, "running", "运行中 (1 个项目)，压缩中 1"},
+	}
+
+	for _, tt := range tests {
+		got := summarizeStatuses(tt.statuses)
+		if got.Icon != tt.icon || got.Text != tt.text {
+			t.Errorf("%s: got (%q, %q), want (%q, %q)", tt.name, got.Icon, got.Text, tt.icon, tt.text)
+		}
+	}
+}
+
+func TestActiveStatuses(t *testing.T) {
+	now := int64(10000)
+	statuses := []monitor.ProjectStatus{
+		{SessionId: "fresh", Status: "idle", UpdatedAt: now - 10},
+		{SessionId: "timed-out", Status: "idle", UpdatedAt: now - 600},
+		{SessionId: "tracked", Status: "idle", UpdatedAt: now - 600, Pid: 42},
+		{SessionId: "stopped", Status: "stopped", UpdatedAt: now, Pid: 42},
+	}
+
+	got := activeStatuses(statuses, now, 300)
+	if len(got) != 2 || got[0].SessionId != "fresh" || got[1].SessionId != "tracked" {
+		t.Errorf("activeStatuses = %+v, want fresh and tracked", got)
+	}
+
+	if got := activeStatuses(statuses, now, 0); len(got) != 3 {
+		t.Errorf("timeout disabled: got %d statuses, want 3", len(got))
+	}
+}
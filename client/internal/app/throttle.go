@@ -0,0 +1,54 @@
+package app
+
+import (
+	"time"
+
+	"claude-status/internal/monitor"
+)
+
+// updateThrottle 限制托盘图标与悬浮窗口的刷新频率：距上次刷新不足 interval 时只保留最新的状态，
+// 间隔结束时再刷新一次（后到的状态总是完整快照，丢弃中间的不影响结果）。interval 为 0 时不限制
+type updateThrottle struct {
+	interval time.Duration
+	last     time.Time               // 上次刷新的时间
+	pending  []monitor.ProjectStatus // 等待刷新的最新状态
+	waiting  bool                    // 已有待刷新的状态（调用方的定时器在运行）
+
+	received int // 收到的状态消息数
+	applied  int // 实际刷新的次数
+}
+
+// Receive 记录收到的状态。apply 为 true 时应立即刷新；否则状态被保留，wait > 0 时调用方需在
+// wait 后调用 Flush（已有定时器在运行时 wait 为 0）
+func (t *updateThrottle) Receive(statuses []monitor.ProjectStatus, now time.Time) (apply bool, wait time.Duration) {
+	t.received++
+	if !t.waiting && (t.interval <= 0 || now.Sub(t.last) >= t.interval) {
+		t.last = now
+		t.applied++
+		return true, 0
+	}
+	t.pending = statuses
+	if t.waiting {
+		return false, 0
+	}
+	t.waiting = true
+	return false, t.last.Add(t.interval).Sub(now)
+}
+
+// Flush 取出等待刷新的状态，没有时返回 false
+func (t *updateThrottle) Flush(now time.Time) ([]monitor.ProjectStatus, bool) {
+	if !t.waiting {
+		return nil, false
+	}
+	statuses := t.pending
+	t.pending = nil
+	t.waiting = false
+	t.last = now
+	t.applied++
+	return statuses, true
+}
+
+// Stats 返回收到的状态消息数与实际刷新次数
+func (t *updateThrottle) Stats() (received, applied int) {
+	return t.received, t.applied
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"claude-status/internal/monitor"
+)
+
+func TestUpdateThrottle(t *testing.T) {
+	st := func(id string) []monitor.ProjectStatus {
+		return []monitor.ProjectStatus{{SessionId: id}}
+	}
+	base := time.Unix(1000, 0)
+	tr := &updateThrottle{interval: 500 * time.Millisecond}
+
+	// 首次立即刷新
+	if apply, _ := tr.Receive(st("a"), base); !apply {
+		t.Fatal("first Receive should apply")
+	}
+	// 间隔内的状态被保留，只有第一次返回等待时长
+	apply, wait := tr.Receive(st("b"), base.Add(100*time.Millisecond))
+	if apply || wait != 400*time.Millisecond {
+		t.Fatalf("Receive = %v, %v, want false, 400ms", apply, wait)
+	}
+	if apply, wait := tr.Receive(st("c"), base.Add(200*time.Millisecond)); apply || wait != 0 {
+		t.Fatalf("Receive = %v, %v, want false, 0", apply, wait)
+	}
+	// 定时器到期时刷新最新的状态
+	got, ok := tr.Flush(base.Add(500 * time.Millisecond))
+	if !ok || got[0].SessionId != "c" {
+		t.Fatalf("Flush = %v, %v, want c", got, ok)
+	}
+	if _, ok := tr.Flush(base.Add(600 * time.Millisecond)); ok {
+		t.Error("Flush without pending statuses should return false")
+	}
+	// 间隔结束后再次立即刷新
+	if apply, _ := tr.Receive(st("d"), base.Add(time.Second)); !apply {
+		t.Error("Receive after interval should apply")
+	}
+	if received, applied := tr.Stats(); received != 4 || applied != 3 {
+		t.Errorf("Stats = %d, %d, want 4, 3", received, applied)
+	}
+
+	// interval 为 0 时不限制
+	unlimited := &updateThrottle{}
+	for i := 0; i < 3; i++ {
+		if apply, _ := unlimited.Receive(st("a"), base); !apply {
+			t.Fatal("unlimited throttle should always apply")
+		}
+	}
+}
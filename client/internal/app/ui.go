@@ -1,6 +1,8 @@
 package app
 
 import (
+	"time"
+
 	"claude-status/internal/config"
 	"claude-status/internal/monitor"
 )
@@ -42,6 +44,20 @@ type UI interface {
 	// UpdatePopup updates the popup window with session statuses.
 	UpdatePopup(statuses []monitor.ProjectStatus)
 
+	// UpdateUsageBlock updates the usage window line at the top of the popup.
+	// block is nil when there is no active window; limit <= 0 hides the percentage.
+	UpdateUsageBlock(block *monitor.UsageBlock, limit int64)
+
+	// SetLatency shows the round-trip time of the monitor connection in the popup; 0 hides it.
+	SetLatency(rtt time.Duration)
+
+	// SetClockSkew sets the server clock offset (server - client) used for durations in the popup,
+	// and warns in the tray tooltip when it exceeds monitor.SkewWarnThreshold.
+	SetClockSkew(skew time.Duration)
+
+	// Notify shows a transient notification (tray balloon) to the user.
+	Notify(title string, message string)
+
 	// QuitChan returns a channel that is closed when the user requests quit.
 	QuitChan() <-chan struct{}
 
@@ -50,4 +66,16 @@ type UI interface {
 
 	// ServerSelectChan returns a channel that receives the selected server config.
 	ServerSelectChan() <-chan config.ServerConfig
+
+	// AttachChan returns a channel that receives the session the user wants to attach to.
+	AttachChan() <-chan monitor.ProjectStatus
+
+	// ReplyChan returns a channel that receives replies the user has confirmed.
+	ReplyChan() <-chan monitor.ReplyRequest
+
+	// RefreshChan returns a channel that receives when the user requests a full status resync.
+	RefreshChan() <-chan struct{}
+
+	// RemoveChan returns a channel that receives the session the user wants removed from the server.
+	RemoveChan() <-chan monitor.ProjectStatus
 }
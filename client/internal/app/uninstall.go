@@ -5,11 +5,7 @@ package app
 import (
 	"fmt"
 
-	"claude-status/internal/config"
-	"claude-status/internal/installer"
 	"claude-status/internal/logger"
-	"claude-status/internal/monitor"
-	"claude-status/internal/wsl"
 )
 
 // RunUninstall 读取配置并在服务端执行卸载脚本，
@@ -30,24 +26,9 @@ func RunUninstall(configPath string, purge bool) error {
 
 	logger.Info("RunUninstall: configPath=%s purge=%v", configPath, purge)
 
-	if !config.Exists(configPath) {
-		return fmt.Errorf("配置文件不存在: %s", configPath)
-	}
-
-	cfg, err := config.Load(configPath)
+	inst, err := connectInstaller(configPath)
 	if err != nil {
-		return fmt.Errorf("加载配置失败: %w", err)
-	}
-
-	var inst monitor.Installer
-	if cfg.WSL.Enabled {
-		inst = wsl.NewInstaller(cfg)
-	} else {
-		inst = installer.NewInstaller(cfg)
-	}
-
-	if err := inst.Connect(); err != nil {
-		return fmt.Errorf("连接失败: %w", err)
+		return err
 	}
 	defer inst.Close()
 
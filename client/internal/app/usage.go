@@ -0,0 +1,133 @@
+package app
+
+import (
+	"fmt"
+
+	"claude-status/internal/config"
+	"claude-status/internal/monitor"
+)
+
+// estimateCost 按配置单价估算 Token 用量的费用（美元），未配置单价的模型不计入
+func estimateCost(cfg config.UsageConfig, usage map[string]monitor.ModelUsage) float64 {
+	var cost float64
+	for model, u := range usage {
+		price, ok := cfg.PriceFor(model)
+		if !ok {
+			continue
+		}
+		cost += (float64(u.InputTokens)*price.Input +
+			float64(u.OutputTokens)*price.Output +
+			float64(u.CacheCreationTokens)*price.CacheWrite +
+			float64(u.CacheReadTokens)*price.CacheRead) / 1e6
+	}
+	return cost
+}
+
+// applyCosts 为带有 Token 用量的会话填写预估费用
+func applyCosts(statuses []monitor.ProjectStatus, cfg config.UsageConfig) {
+	for i := range statuses {
+		statuses[i].EstimatedCost = estimateCost(cfg, statuses[i].Usage)
+	}
+}
+
+// projectUsage 单个项目所有会话的合计用量
+type projectUsage struct {
+	Tokens int64
+	Cost   float64
+}
+
+// projectTotals 按项目目录汇总 Token 数与预估费用（需先调用 applyCosts）
+func projectTotals(statuses []monitor.ProjectStatus) map[string]projectUsage {
+	totals := make(map[string]projectUsage)
+	for _, s := range statuses {
+		t := totals[s.Project]
+		t.Tokens += s.TotalTokens()
+		t.Cost += s.EstimatedCost
+		totals[s.Project] = t
+	}
+	return totals
+}
+
+// budgetTracker 预算提醒：每个会话、每个项目超过预算只提醒一次
+type budgetTracker struct {
+	sessions map[string]bool
+	projects map[string]bool
+	primed   bool
+}
+
+// newBudgetTracker 创建预算跟踪器
+func newBudgetTracker() *budgetTracker {
+	return &budgetTracker{sessions: make(map[string]bool), projects: make(map[string]bool)}
+}
+
+// Exceeded 返回本次新超过预算的会话与项目提醒（需先调用 applyCosts）。
+// 首次调用只记录不返回，避免重连时重复提醒。
+func (t *budgetTracker) Exceeded(statuses []monitor.ProjectStatus, cfg config.UsageConfig) []Notification {
+	var result []Notification
+	alert := func(n Notification) {
+		if t.primed {
+			result = append(result, n)
+		}
+	}
+
+	if cfg.SessionBudget > 0 {
+		for _, s := range statuses {
+			if s.Status == monitor.StatusStopped || s.EstimatedCost < cfg.SessionBudget || t.sessions[s.SessionId] {
+				continue
+			}
+			t.sessions[s.SessionId] = true
+			alert(Notification{
+				SessionId:   s.SessionId,
+				Project:     s.Project,
+				ProjectName: s.ProjectName,
+				Type:        "budget",
+				Message: fmt.Sprintf("会话预估费用 %s 已超过预算 %s",
+					monitor.FormatCost(s.EstimatedCost), monitor.FormatCost(cfg.SessionBudget)),
+			})
+		}
+	}
+
+	if cfg.ProjectBudget > 0 {
+		totals := projectTotals(statuses)
+		for _, s := range statuses {
+			total := totals[s.Project]
+			if total.Cost < cfg.ProjectBudget || t.projects[s.Project] {
+				continue
+			}
+			t.projects[s.Project] = true
+			alert(Notification{
+				Project:     s.Project,
+				ProjectName: s.ProjectName,
+				Type:        "budget",
+				Message: fmt.Sprintf("项目预估费用 %s 已超过预算 %s",
+					monitor.FormatCost(total.Cost), monitor.FormatCost(cfg.ProjectBudget)),
+			})
+		}
+	}
+
+	t.primed = true
+	return result
+}
+
+// blockTracker 用量窗口提醒：每个窗口达到阈值只提醒一次
+type blockTracker struct {
+	alerted int64 // 已提醒窗口的开始时间
+}
+
+// Reached 窗口用量首次达到 BlockLimit 的 BlockAlertPercent 时返回提醒，now 为服务端当前时间
+func (t *blockTracker) Reached(block *monitor.UsageBlock, cfg config.UsageConfig, now int64) *Notification {
+	if block == nil || cfg.BlockLimit <= 0 || cfg.BlockAlertPercent <= 0 || t.alerted == block.Start {
+		return nil
+	}
+	if block.Tokens*100 < cfg.BlockLimit*int64(cfg.BlockAlertPercent) {
+		return nil
+	}
+	t.alerted = block.Start
+	return &Notification{
+		ProjectName: "用量窗口",
+		Type:        "usage_limit",
+		Message: fmt.Sprintf("已用 %s tok（上限的 %d%%），%s 后重置",
+			monitor.FormatTokens(block.Tokens), block.Percent(cfg.BlockLimit),
+			monitor.FormatDuration(block.SecondsToReset(now))),
+	}
+}
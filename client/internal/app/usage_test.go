@@ -0,0 +1,73 @@
+package app
+
+import (
+	"math"
+	"testing"
+
+	"claude-status/internal/config"
+	"claude-status/internal/monitor"
+)
+
+func TestEstimateCost(t *testing.T) {
+	cfg := config.UsageConfig{
+		Pricing: map[string]config.ModelPrice{
+			"claude-sonnet-4-5": {Input: 4, Output: 20, CacheRead: 0.5},
+		},
+	}
+	usage := map[string]monitor.ModelUsage{
+		// 内置 opus 单价：15 / 75，缓存写入 18.75，缓存读取 1.5
+		"claude-opus-4-1": {InputTokens: 1e6, OutputTokens: 1e6, CacheCreationTokens: 1e6, CacheReadTokens: 1e6},
+		// 最长匹配的自定义单价
+		"claude-sonnet-4-5-20250929": {InputTokens: 1e6, CacheReadTokens: 2e6},
+		// 未知模型不计入
+		"unknown": {InputTokens: 1e6},
+	}
+
+	want := 15 + 75 + 18.75 + 1.5 + 4 + 1.0
+	if got := estimateCost(cfg, usage); math.Abs(got-want) > 1e-9 {
+		t.Errorf("estimateCost = %v, want %v", got, want)
+	}
+}
+
+func TestBudgetTracker(t *testing.T) {
+	cfg := config.UsageConfig{SessionBudget: 5, ProjectBudget: 8}
+	st := func(id, project string, cost float64) monitor.ProjectStatus {
+		return monitor.ProjectStatus{SessionId: id, Project: project, ProjectName: project, Status: "working", EstimatedCost: cost}
+	}
+	tr := newBudgetTracker()
+
+	// 首次调用只记录
+	if got := tr.Exceeded([]monitor.ProjectStatus{st("a", "p", 6)}, cfg); len(got) != 0 {
+		t.Fatalf("first call: got %d alerts, want 0", len(got))
+	}
+
+	// b 超过会话预算，项目 p 合计 12 超过项目预算；a 已提醒过
+	got := tr.Exceeded([]monitor.ProjectStatus{st("a", "p", 6), st("b", "p", 6)}, cfg)
+	if len(got) != 2 || got[0].SessionId != "b" || got[1].SessionId != "" || got[1].Project != "p" {
+		t.Fatalf("got %+v, want session b and project p alerts", got)
+	}
+
+	// 不重复提醒
+	if got := tr.Exceeded([]monitor.ProjectStatus{st("a", "p", 7), st("b", "p", 7)}, cfg); len(got) != 0 {
+		t.Fatalf("repeat: got %+v, want none", got)
+	}
+}
+
+func TestBlockTracker(t *testing.T) {
+	cfg := config.UsageConfig{BlockLimit: 1000, BlockAlertPercent: 80}
+	var tr blockTracker
+
+	if n := tr.Reached(&monitor.UsageBlock{Start: 100, End: 18100, Tokens: 700}, cfg, 100); n != nil {
+		t.Fatalf("below threshold: got %+v", n)
+	}
+	if n := tr.Reached(&monitor.UsageBlock{Start: 100, End: 18100, Tokens: 800}, cfg, 100); n == nil || n.Type != "usage_limit" {
+		t.Fatalf("at threshold: got %+v, want usage_limit alert", n)
+	}
+	// 同一窗口不重复提醒，新窗口重新提醒
+	if n := tr.Reached(&monitor.UsageBlock{Start: 100, End: 18100, Tokens: 900}, cfg, 100); n != nil {
+		t.Fatalf("repeat: got %+v", n)
+	}
+	if n := tr.Reached(&monitor.UsageBlock{Start: 18100, End: 36100, Tokens: 900}, cfg, 100); n == nil {
+		t.Fatal("new block: want alert")
+	}
+}
@@ -3,7 +3,10 @@ package config
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/kevinburke/ssh_config"
 	"gopkg.in/yaml.v3"
@@ -40,10 +43,181 @@ func DefaultConfigPath() string {
 
 // Config 应用配置
 type Config struct {
-	Server        ServerConfig `yaml:"server"`
-	WSL           WSLConfig    `yaml:"wsl,omitempty"`
-	Debug         bool         `yaml:"debug,omitempty"`
-	StatusTimeout int          `yaml:"status_timeout,omitempty"` // 状态超时（秒），默认 300，0 禁用
+	Server        ServerConfig       `yaml:"server"`
+	WSL           WSLConfig          `yaml:"wsl,omitempty"`
+	Install       InstallConfig      `yaml:"install,omitempty"`
+	Notify        NotifyConfig       `yaml:"notify,omitempty"`
+	Usage         UsageConfig        `yaml:"usage,omitempty"`
+	Monitor       MonitorConfig      `yaml:"monitor,omitempty"`
+	ServerNotify  ServerNotifyConfig `yaml:"server_notify,omitempty"`
+	Debug         bool               `yaml:"debug,omitempty"`
+	StatusTimeout int                `yaml:"status_timeout,omitempty"` // 状态超时（秒），默认 300，0 禁用；记录了 pid 的会话不受此限制
+}
+
+// Hook 安装范围
+const (
+	ScopeUser      = "user"       // ${CLAUDE_CONFIG_DIR:-~/.claude}/settings.json
+	ScopeProject   = "project"    // <repo>/.claude/settings.json
+	ScopeLocal     = "local"      // <repo>/.claude/settings.local.json
+	ScopeConfigDir = "config_dir" // <dir>/settings.json，对应自定义的 CLAUDE_CONFIG_DIR
+)
+
+// Hook 安装模式
+const (
+	ModeSettings = "settings" // 直接写入 settings.json 的 hooks 字段
+	ModePlugin   = "plugin"   // 打包为 Claude Code 插件，通过 claude plugin 命令启用
+)
+
+// InstallConfig 服务端 Hook 安装配置
+type InstallConfig struct {
+	Mode       string   `yaml:"mode,omitempty"`        // settings | plugin，默认 settings
+	Scope      string   `yaml:"scope,omitempty"`       // user | project | local | config_dir，默认 user
+	Projects   []string `yaml:"projects,omitempty"`    // scope 为 project/local 时的仓库路径（服务端路径）
+	ConfigDirs []string `yaml:"config_dirs,omitempty"` // scope 为 config_dir 时的 Claude 配置目录（服务端路径）
+}
+
+// NotifyConfig 会话通知转发配置（Notification Hook 的消息），均为可选
+type NotifyConfig struct {
+	Balloon bool   `yaml:"balloon,omitempty"` // 托盘气泡通知
+	Command string `yaml:"command,omitempty"` // 本地命令（cmd /C 执行），通知内容通过 CLAUDE_STATUS_* 环境变量传入
+	Replay  bool   `yaml:"replay,omitempty"`  // 重连后为离线期间变为等待输入/授权的会话补发通知（离线汇总总是显示）
+}
+
+// UsageConfig Token 用量的费用估算与预算提醒，均为可选
+type UsageConfig struct {
+	// Pricing 模型单价，键为模型名中的子串（如 "opus"、"claude-sonnet-4"），
+	// 多个键匹配时取最长者；与内置单价同名的键覆盖内置值
+	Pricing       map[string]ModelPrice `yaml:"pricing,omitempty"`
+	SessionBudget float64               `yaml:"session_budget,omitempty"` // 单个会话预估费用（美元）超过该值时提醒，0 不提醒
+	ProjectBudget float64               `yaml:"project_budget,omitempty"` // 单个项目所有会话合计超过该值时提醒，0 不提醒
+
+	// BlockLimit 5 小时用量窗口的 Token 上限（按自己的套餐估计填写），设置后显示已用百分比；
+	// BlockAlertPercent 窗口用量达到上限的该百分比时提醒（每个窗口一次），0 不提醒
+	BlockLimit        int64 `yaml:"block_limit,omitempty"`
+	BlockAlertPercent int   `yaml:"block_alert_percent,omitempty"`
+}
+
+// MonitorConfig 监控连接的设置，除 UpdateInterval 外在连接建立后经控制通道发送给服务端，均为可选
+type MonitorConfig struct {
+	// Projects 只显示这些项目的会话：服务端项目目录（含其子目录）或项目名，为空显示全部
+	Projects []string `yaml:"projects,omitempty"`
+	// Retention 服务端状态文件的保留时长（分钟），超过该时长未更新且进程已退出的会话被清理；
+	// 0 使用服务端默认的 60 分钟
+	Retention int `yaml:"retention,omitempty"`
+	// StoppedRetention 已结束（stopped）会话的保留时长（分钟），0 与 Retention 相同
+	StoppedRetention int `yaml:"stopped_retention,omitempty"`
+	// Archive 清理的会话归档到服务端 ~/.claude-status/history/ 而不是直接删除
+	Archive bool `yaml:"archive,omitempty"`
+	// Debounce 服务端合并状态变化的窗口（毫秒，最大 5000），窗口内的多次变化只输出一次完整状态；
+	// 0 使用默认的 200，-1 关闭
+	Debounce int `yaml:"debounce,omitempty"`
+	// UpdateInterval 客户端刷新托盘图标与悬浮窗口的最小间隔（毫秒），0 使用默认的 500，-1 不限制
+	UpdateInterval int `yaml:"update_interval,omitempty"`
+}
+
+// GetDebounce 返回服务端合并窗口（毫秒），0 表示关闭
+func (m MonitorConfig) GetDebounce() int {
+	switch {
+	case m.Debounce == 0:
+		return 200
+	case m.Debounce < 0:
+		return 0
+	}
+	return m.Debounce
+}
+
+// GetUpdateInterval 返回客户端刷新的最小间隔，0 表示不限制
+func (m MonitorConfig) GetUpdateInterval() time.Duration {
+	switch {
+	case m.UpdateInterval == 0:
+		return 500 * time.Millisecond
+	case m.UpdateInterval < 0:
+		return 0
+	}
+	return time.Duration(m.UpdateInterval) * time.Millisecond
+}
+
+// GetRetention 返回未结束会话的保留时长（分钟），未配置时为 60
+func (m MonitorConfig) GetRetention() int {
+	if m.Retention == 0 {
+		return 60
+	}
+	return m.Retention
+}
+
+// GetStoppedRetention 返回已结束会话的保留时长（分钟），未配置时与 GetRetention 相同
+func (m MonitorConfig) GetStoppedRetention() int {
+	if m.StoppedRetention == 0 {
+		return m.GetRetention()
+	}
+	return m.StoppedRetention
+}
+
+// Validate 校验监控设置（控制命令以空格分隔参数，项目不能包含空白字符）
+func (m MonitorConfig) Validate() error {
+	for _, p := range m.Projects {
+		if p == "" || strings.ContainsAny(p, " \t") {
+			return fmt.Errorf("monitor.projects 中的项目不能为空或包含空白字符: %q", p)
+		}
+	}
+	if m.Retention < 0 {
+		return fmt.Errorf("无效的 monitor.retention: %d（分钟，0 使用默认值）", m.Retention)
+	}
+	if m.StoppedRetention < 0 {
+		return fmt.Errorf("无效的 monitor.stopped_retention: %d（分钟，0 与 retention 相同）", m.StoppedRetention)
+	}
+	if m.Debounce < -1 || m.Debounce > 5000 {
+		return fmt.Errorf("无效的 monitor.debounce: %d（毫秒，最大 5000，0 使用默认值，-1 关闭）", m.Debounce)
+	}
+	if m.UpdateInterval < -1 {
+		return fmt.Errorf("无效的 monitor.update_interval: %d（毫秒，0 使用默认值，-1 不限制）", m.UpdateInterval)
+	}
+	return nil
+}
+
+// ModelPrice 模型单价（美元 / 百万 Token）
+type ModelPrice struct {
+	Input      float64 `yaml:"input"`
+	Output     float64 `yaml:"output"`
+	CacheWrite float64 `yaml:"cache_write,omitempty"` // 缓存写入，未配置时为 Input × 1.25
+	CacheRead  float64 `yaml:"cache_read,omitempty"`  // 缓存读取，未配置时为 Input × 0.1
+}
+
+// DefaultPricing 内置的模型单价，可通过 usage.pricing 覆盖
+var DefaultPricing = map[string]ModelPrice{
+	"opus":   {Input: 15, Output: 75},
+	"sonnet": {Input: 3, Output: 15},
+	"haiku":  {Input: 0.8, Output: 4},
+}
+
+// PriceFor 返回模型的单价（已补全缓存单价），未匹配到任何键时返回 false
+func (u UsageConfig) PriceFor(model string) (ModelPrice, bool) {
+	var best string
+	var price ModelPrice
+	match := func(key string, p ModelPrice) {
+		if strings.Contains(model, key) && len(key) > len(best) {
+			best, price = key, p
+		}
+	}
+	for key, p := range DefaultPricing {
+		if _, overridden := u.Pricing[key]; !overridden {
+			match(key, p)
+		}
+	}
+	for key, p := range u.Pricing {
+		match(key, p)
+	}
+	if best == "" {
+		return ModelPrice{}, false
+	}
+
+	if price.CacheWrite == 0 {
+		price.CacheWrite = price.Input * 1.25
+	}
+	if price.CacheRead == 0 {
+		price.CacheRead = price.Input * 0.1
+	}
+	return price, true
 }
 
 // WSLConfig WSL 配置
@@ -60,6 +234,9 @@ type ServerConfig struct {
 	User          string `yaml:"user"`
 	IdentityFile  string `yaml:"identity_file"`
 	SSHConfigPath string `yaml:"ssh_config_path"`
+	// MonitorIdentityFile 监控连接专用密钥（由 provision 生成，服务端限制为只能运行 monitor.sh）。
+	// 为空时监控连接与安装共用 IdentityFile。
+	MonitorIdentityFile string `yaml:"monitor_identity_file,omitempty"`
 }
 
 // Exists 检查配置文件是否存在
@@ -96,6 +273,15 @@ func Load(path string) (*Config, error) {
 	if cfg.Server.Host == "" {
 		return nil, fmt.Errorf("缺少必要配置: server.host")
 	}
+	if err := cfg.Install.Validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Monitor.Validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.ServerNotify.Validate(); err != nil {
+		return nil, err
+	}
 
 	return &cfg, nil
 }
@@ -243,6 +429,80 @@ func (c *Config) ApplySSHConfig() error {
 	return nil
 }
 
+// GetScope 返回安装范围，未配置时为 user
+func (i InstallConfig) GetScope() string {
+	if i.Scope == "" {
+		return ScopeUser
+	}
+	return i.Scope
+}
+
+// GetMode 返回安装模式，未配置时为 settings
+func (i InstallConfig) GetMode() string {
+	if i.Mode == "" {
+		return ModeSettings
+	}
+	return i.Mode
+}
+
+// Validate 校验安装模式、范围与对应的路径列表
+func (i InstallConfig) Validate() error {
+	switch i.GetMode() {
+	case ModeSettings, ModePlugin:
+	default:
+		return fmt.Errorf("无效的 install.mode: %s（可选 settings、plugin）", i.Mode)
+	}
+
+	switch i.GetScope() {
+	case ScopeUser:
+		return nil
+	case ScopeProject, ScopeLocal:
+		if len(i.Projects) == 0 {
+			return fmt.Errorf("install.scope 为 %s 时必须配置 install.projects", i.Scope)
+		}
+	case ScopeConfigDir:
+		if len(i.ConfigDirs) == 0 {
+			return fmt.Errorf("install.scope 为 config_dir 时必须配置 install.config_dirs")
+		}
+	default:
+		return fmt.Errorf("无效的 install.scope: %s（可选 user、project、local、config_dir）", i.Scope)
+	}
+	return nil
+}
+
+// SettingsTargets 返回需要写入 Hook 的服务端 settings 文件路径（可含 ~ 前缀，由服务端展开）。
+// user 范围返回 nil，由服务端脚本按 ${CLAUDE_CONFIG_DIR:-$HOME/.claude}/settings.json 解析。
+func (i InstallConfig) SettingsTargets() []string {
+	var targets []string
+	switch i.GetScope() {
+	case ScopeProject:
+		for _, p := range i.Projects {
+			targets = append(targets, path.Join(p, ".claude", "settings.json"))
+		}
+	case ScopeLocal:
+		for _, p := range i.Projects {
+			targets = append(targets, path.Join(p, ".claude", "settings.local.json"))
+		}
+	case ScopeConfigDir:
+		for _, d := range i.ConfigDirs {
+			targets = append(targets, path.Join(d, "settings.json"))
+		}
+	}
+	return targets
+}
+
+// PluginDirs 返回插件模式下需要启用插件的服务端目录：
+// project/local 为仓库路径，config_dir 为 Claude 配置目录，user 范围返回 nil。
+func (i InstallConfig) PluginDirs() []string {
+	switch i.GetScope() {
+	case ScopeProject, ScopeLocal:
+		return i.Projects
+	case ScopeConfigDir:
+		return i.ConfigDirs
+	}
+	return nil
+}
+
 // GetIdentityFile 获取密钥文件路径，如果未配置则返回默认路径
 func (c *Config) GetIdentityFile() string {
 	if c.Server.IdentityFile != "" {
@@ -270,6 +530,15 @@ func (c *Config) GetIdentityFile() string {
 	return filepath.Join(home, ".ssh", "id_rsa")
 }
 
+// GetMonitorIdentityFile 获取监控连接使用的密钥路径，未配置专用密钥时回退到 GetIdentityFile。
+// 安装、升级等需要完整 shell 的操作始终使用 GetIdentityFile。
+func (c *Config) GetMonitorIdentityFile() string {
+	if c.Server.MonitorIdentityFile != "" {
+		return expandPath(c.Server.MonitorIdentityFile)
+	}
+	return c.GetIdentityFile()
+}
+
 // expandPath 展开路径中的 ~ 符号
 func expandPath(path string) string {
 	if len(path) > 0 && path[0] == '~' {
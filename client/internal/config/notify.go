@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+// 服务端通知的触发状态
+const (
+	ServerNotifyIdle       = "idle"       // 一轮结束（运行中 → 等待输入）
+	ServerNotifyPermission = "permission" // 等待授权
+	ServerNotifyStopped    = "stopped"    // 会话结束
+)
+
+// ServerNotifyConfig 服务端通知：没有客户端连接（租约已过期）时由服务端守护进程直接推送会话提醒，
+// 笔记本合上时也能收到。安装时写入服务端 ~/.claude-status/notify.conf（权限 0600），均为可选，
+// webhook、smtp、command 都未配置时关闭
+type ServerNotifyConfig struct {
+	Webhook string           `yaml:"webhook,omitempty"` // 以 POST 发送 JSON 到该 URL（需要服务端有 curl）
+	SMTP    ServerNotifySMTP `yaml:"smtp,omitempty"`    // 经 SMTP 中继发送邮件（需要服务端有 curl）
+	Command string           `yaml:"command,omitempty"` // 服务端命令（bash -c），事件经 CLAUDE_STATUS_* 环境变量传入
+	// Events 触发通知的状态，见 ServerNotify* 常量，为空时为 idle 与 permission
+	Events []string `yaml:"events,omitempty"`
+	// Always 客户端连接期间也推送（默认只在没有有效租约时推送）
+	Always bool `yaml:"always,omitempty"`
+}
+
+// ServerNotifySMTP SMTP 中继配置
+type ServerNotifySMTP struct {
+	URL      string   `yaml:"url,omitempty"`      // smtp://host:587 或 smtps://host:465
+	From     string   `yaml:"from,omitempty"`     // 发件人地址
+	To       []string `yaml:"to,omitempty"`       // 收件人地址
+	Username string   `yaml:"username,omitempty"` // 可选的认证用户名
+	Password string   `yaml:"password,omitempty"` // 可选的认证密码（明文保存在服务端 notify.conf）
+	StartTLS bool     `yaml:"starttls,omitempty"` // smtp:// 连接要求 STARTTLS
+}
+
+// Enabled 是否配置了任一服务端通知方式
+func (n ServerNotifyConfig) Enabled() bool {
+	return n.Webhook != "" || n.SMTP.URL != "" || n.Command != ""
+}
+
+// GetEvents 返回触发通知的状态，未配置时为 idle 与 permission
+func (n ServerNotifyConfig) GetEvents() []string {
+	if len(n.Events) == 0 {
+		return []string{ServerNotifyIdle, ServerNotifyPermission}
+	}
+	return n.Events
+}
+
+// Validate 校验服务端通知配置（notify.conf 按行保存，各值不能包含换行）
+func (n ServerNotifyConfig) Validate() error {
+	values := []string{n.Webhook, n.Command, n.SMTP.URL, n.SMTP.From, n.SMTP.Username, n.SMTP.Password}
+	values = append(append(values, n.SMTP.To...), n.Events...)
+	for _, v := range values {
+		if strings.ContainsAny(v, "\r\n") {
+			return fmt.Errorf("server_notify 的配置值不能包含换行: %q", v)
+		}
+	}
+
+	if n.Webhook != "" {
+		if u, err := url.Parse(n.Webhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("无效的 server_notify.webhook: %q（须为 http 或 https URL）", n.Webhook)
+		}
+	}
+
+	if n.SMTP.URL != "" {
+		if u, err := url.Parse(n.SMTP.URL); err != nil || (u.Scheme != "smtp" && u.Scheme != "smtps") || u.Host == "" {
+			return fmt.Errorf("无效的 server_notify.smtp.url: %q（须为 smtp:// 或 smtps://）", n.SMTP.URL)
+		}
+		if len(n.SMTP.To) == 0 {
+			return fmt.Errorf("server_notify.smtp.to 不能为空")
+		}
+		for _, addr := range append([]string{n.SMTP.From}, n.SMTP.To...) {
+			if _, err := mail.ParseAddress(addr); err != nil || strings.ContainsAny(addr, "<> ") {
+				return fmt.Errorf("无效的 server_notify.smtp 邮件地址: %q", addr)
+			}
+		}
+	}
+
+	for _, e := range n.Events {
+		switch e {
+		case ServerNotifyIdle, ServerNotifyPermission, ServerNotifyStopped:
+		default:
+			return fmt.Errorf("无效的 server_notify.events: %q（可选 idle、permission、stopped）", e)
+		}
+	}
+	return nil
+}
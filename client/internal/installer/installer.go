@@ -11,6 +11,7 @@ import (
 
 	"claude-status/internal/config"
 	"claude-status/internal/logger"
+	"claude-status/internal/monitor"
 	sshclient "claude-status/internal/ssh"
 	"claude-status/internal/version"
 
@@ -29,6 +30,24 @@ var installRemoteScriptTemplate string
 //go:embed scripts/uninstall-remote.sh
 var uninstallRemoteScriptTemplate string
 
+//go:embed scripts/backups.sh
+var backupsScriptTemplate string
+
+//go:embed scripts/doctor.sh
+var doctorScriptTemplate string
+
+//go:embed scripts/plugin.sh
+var pluginScriptTemplate string
+
+//go:embed scripts/provision.sh
+var provisionScriptTemplate string
+
+//go:embed scripts/reply.sh
+var replyScriptTemplate string
+
+//go:embed scripts/hooks.json
+var hooksTemplate string
+
 // GetStatusHookScript 返回替换版本号后的脚本
 func GetStatusHookScript() string {
 	return stripCR(strings.ReplaceAll(statusHookScriptTemplate, "__VERSION__", version.Version))
@@ -49,12 +68,48 @@ func GetUninstallRemoteScript() string {
 	return stripCR(strings.ReplaceAll(uninstallRemoteScriptTemplate, "__VERSION__", version.Version))
 }
 
+// GetBackupsScript 返回替换版本号后的备份管理脚本
+func GetBackupsScript() string {
+	return stripCR(strings.ReplaceAll(backupsScriptTemplate, "__VERSION__", version.Version))
+}
+
+// GetDoctorScript 返回替换版本号后的诊断脚本
+func GetDoctorScript() string {
+	return stripCR(strings.ReplaceAll(doctorScriptTemplate, "__VERSION__", version.Version))
+}
+
+// GetPluginScript 返回替换版本号后的插件安装脚本
+func GetPluginScript() string {
+	return stripCR(strings.ReplaceAll(pluginScriptTemplate, "__VERSION__", version.Version))
+}
+
+// GetProvisionScript 返回监控密钥授权脚本
+func GetProvisionScript() string {
+	return stripCR(provisionScriptTemplate)
+}
+
+// GetReplyScript 返回替换版本号后的会话回复脚本
+func GetReplyScript() string {
+	return stripCR(strings.ReplaceAll(replyScriptTemplate, "__VERSION__", version.Version))
+}
+
+// GetHooksTemplate 返回 Hook 事件映射模板（settings 模式与插件模式共用）
+func GetHooksTemplate() string {
+	return stripCR(hooksTemplate)
+}
+
 // 为保持兼容性，提供变量访问（延迟初始化）
 var (
 	StatusHookScript      = ""
 	MonitorScript         = ""
 	InstallRemoteScript   = ""
 	UninstallRemoteScript = ""
+	BackupsScript         = ""
+	DoctorScript          = ""
+	PluginScript          = ""
+	HooksTemplate         = ""
+	ProvisionScript       = ""
+	ReplyScript           = ""
 )
 
 func init() {
@@ -62,6 +117,12 @@ func init() {
 	MonitorScript = GetMonitorScript()
 	InstallRemoteScript = GetInstallRemoteScript()
 	UninstallRemoteScript = GetUninstallRemoteScript()
+	BackupsScript = GetBackupsScript()
+	DoctorScript = GetDoctorScript()
+	PluginScript = GetPluginScript()
+	HooksTemplate = GetHooksTemplate()
+	ProvisionScript = GetProvisionScript()
+	ReplyScript = GetReplyScript()
 }
 
 // stripCR 去除 Windows CRLF 中的 \r，确保 shell 脚本在 Linux 上可正常执行
@@ -69,6 +130,111 @@ func stripCR(s string) string {
 	return strings.ReplaceAll(s, "\r\n", "\n")
 }
 
+// InstallRemoteArgs 返回 install-remote.sh 的参数：安装范围及目标 settings 文件
+func InstallRemoteArgs(cfg *config.Config) []string {
+	args := []string{"--scope", cfg.Install.GetScope()}
+	return append(args, cfg.Install.SettingsTargets()...)
+}
+
+// PluginInstallArgs 返回 plugin.sh install 的参数：安装范围及启用插件的目录
+func PluginInstallArgs(cfg *config.Config) []string {
+	args := []string{"install", "--scope", cfg.Install.GetScope()}
+	return append(args, cfg.Install.PluginDirs()...)
+}
+
+// ConfigureHooksCommand 按安装模式返回配置 Hook 的脚本及参数。
+// 插件模式执行已上传的 plugin.sh，settings 模式执行嵌入的 install-remote.sh。
+func ConfigureHooksCommand(cfg *config.Config) (string, []string) {
+	if cfg.Install.GetMode() == config.ModePlugin {
+		return `exec "$HOME/.claude-status/plugin.sh" "$@"`, PluginInstallArgs(cfg)
+	}
+	return InstallRemoteScript, InstallRemoteArgs(cfg)
+}
+
+// dependencyPackages 服务端依赖的命令及其所在的软件包
+var dependencyPackages = map[string]string{
+	"inotifywait": "inotify-tools",
+	"jq":          "jq",
+	"socat":       "socat",
+	"flock":       "util-linux",
+	"setsid":      "util-linux",
+}
+
+// RequiredCommands 返回服务端必需的命令。配置了服务端通知时还需要守护进程（socat、flock、setsid）：
+// 没有守护进程时 monitor.sh 随连接退出，客户端断开后无人推送通知
+func RequiredCommands(cfg *config.Config) []string {
+	cmds := []string{"inotifywait", "jq"}
+	if cfg.ServerNotify.Enabled() {
+		cmds = append(cmds, "socat", "flock", "setsid")
+	}
+	return cmds
+}
+
+// DependencyCheckCommand 返回检查服务端依赖的命令，每行输出一个已安装的命令名
+func DependencyCheckCommand(cfg *config.Config) string {
+	return fmt.Sprintf("for c in %s; do command -v \"$c\" >/dev/null && echo \"$c\"; done; true",
+		strings.Join(RequiredCommands(cfg), " "))
+}
+
+// MissingPackages 根据 DependencyCheckCommand 的输出返回缺少的软件包（去重，按命令顺序）
+func MissingPackages(cfg *config.Config, output string) []string {
+	found := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		found[strings.TrimSpace(line)] = true
+	}
+	var missing []string
+	seen := make(map[string]bool)
+	for _, cmd := range RequiredCommands(cfg) {
+		pkg := dependencyPackages[cmd]
+		if !found[cmd] && !seen[pkg] {
+			seen[pkg] = true
+			missing = append(missing, pkg)
+		}
+	}
+	return missing
+}
+
+// DependencyMessage 生成缺少依赖时的提示
+func DependencyMessage(cfg *config.Config, missing []string) string {
+	msg := fmt.Sprintf("缺少依赖: %s", strings.Join(missing, ", "))
+	if cfg.ServerNotify.Enabled() {
+		msg += "（已配置服务端通知，需要 socat、flock、setsid 运行守护进程）"
+	}
+	return msg
+}
+
+// NotifyConf 生成服务端 notify.conf：每行 key=value（smtp_to 可出现多次），
+// 未配置服务端通知时为空，monitor.sh 读取到空文件即关闭服务端通知
+func NotifyConf(cfg *config.Config) string {
+	n := cfg.ServerNotify
+	if !n.Enabled() {
+		return ""
+	}
+	var b strings.Builder
+	add := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s=%s\n", key, value)
+		}
+	}
+	add("webhook", n.Webhook)
+	add("smtp_url", n.SMTP.URL)
+	add("smtp_from", n.SMTP.From)
+	for _, to := range n.SMTP.To {
+		add("smtp_to", to)
+	}
+	add("smtp_user", n.SMTP.Username)
+	add("smtp_password", n.SMTP.Password)
+	if n.SMTP.StartTLS {
+		add("smtp_starttls", "1")
+	}
+	add("command", n.Command)
+	add("events", strings.Join(n.GetEvents(), ","))
+	if n.Always {
+		add("always", "1")
+	}
+	return b.String()
+}
+
 // Installer 远程安装器
 type Installer struct {
 	cfg    *config.Config
@@ -130,17 +296,9 @@ func (i *Installer) CheckDependencies() (bool, string) {
 	}
 	defer session.Close()
 
-	// 检查 inotifywait 和 jq
-	output, err := session.CombinedOutput("command -v inotifywait && command -v jq")
-	if err != nil {
-		missing := []string{}
-		if !strings.Contains(string(output), "inotifywait") {
-			missing = append(missing, "inotify-tools")
-		}
-		if !strings.Contains(string(output), "jq") {
-			missing = append(missing, "jq")
-		}
-		return false, fmt.Sprintf("缺少依赖: %s", strings.Join(missing, ", "))
+	output, _ := session.CombinedOutput(DependencyCheckCommand(i.cfg))
+	if missing := MissingPackages(i.cfg, string(output)); len(missing) > 0 {
+		return false, DependencyMessage(i.cfg, missing)
 	}
 	return true, ""
 }
@@ -154,21 +312,12 @@ func (i *Installer) Install() error {
 		return fmt.Errorf("创建目录失败: %w", err)
 	}
 
-	// 2. 上传脚本
-	if err := i.uploadFile("~/.claude-status/hooks/status-hook.sh", StatusHookScript); err != nil {
-		return fmt.Errorf("上传 status-hook.sh 失败: %w", err)
-	}
-
-	if err := i.uploadFile("~/.claude-status/monitor.sh", MonitorScript); err != nil {
-		return fmt.Errorf("上传 monitor.sh 失败: %w", err)
-	}
-
-	// 3. 设置执行权限
-	if err := i.runCommand("chmod +x ~/.claude-status/hooks/status-hook.sh ~/.claude-status/monitor.sh"); err != nil {
-		return fmt.Errorf("设置权限失败: %w", err)
+	// 2. 上传脚本（原子替换并校验，权限随文件一并设置）
+	if err := i.uploadFiles(append(RemoteFiles(), ConfigFiles(i.cfg)...)); err != nil {
+		return err
 	}
 
-	// 4. 配置 Claude Code hooks
+	// 3. 配置 Claude Code hooks
 	if err := i.configureHooks(); err != nil {
 		return fmt.Errorf("配置 hooks 失败: %w", err)
 	}
@@ -186,29 +335,13 @@ func (i *Installer) Uninstall(purge bool) error {
 		logger.Info("开始远程卸载...")
 	}
 
-	session, err := i.client.NewSession()
-	if err != nil {
-		return fmt.Errorf("创建会话失败: %w", err)
-	}
-	defer session.Close()
-
-	stdin, err := session.StdinPipe()
-	if err != nil {
-		return fmt.Errorf("获取 stdin 失败: %w", err)
-	}
-
-	go func() {
-		defer stdin.Close()
-		io.WriteString(stdin, UninstallRemoteScript)
-	}()
-
-	cmd := "bash -s"
+	var args []string
 	if purge {
-		cmd = "bash -s -- --purge"
+		args = append(args, "--purge")
 	}
 
-	output, err := session.CombinedOutput(cmd)
-	out := strings.TrimSpace(string(output))
+	output, err := i.RunScript(UninstallRemoteScript, args...)
+	out := strings.TrimSpace(output)
 	if err != nil {
 		return fmt.Errorf("%w (output: %s)", err, out)
 	}
@@ -220,64 +353,47 @@ func (i *Installer) Uninstall(purge bool) error {
 	return nil
 }
 
-// runCommand 执行远程命令
-func (i *Installer) runCommand(cmd string) error {
+// RunScript 以 bash 执行脚本（通过 stdin 传入），返回合并后的 stdout/stderr
+func (i *Installer) RunScript(script string, args ...string) (string, error) {
 	session, err := i.client.NewSession()
 	if err != nil {
-		return err
+		return "", fmt.Errorf("创建会话失败: %w", err)
 	}
 	defer session.Close()
 
-	return session.Run(cmd)
-}
-
-// uploadFile 上传文件内容
-func (i *Installer) uploadFile(remotePath, content string) error {
-	session, err := i.client.NewSession()
-	if err != nil {
-		return err
-	}
-	defer session.Close()
-
-	// 使用 cat 写入文件
 	stdin, err := session.StdinPipe()
 	if err != nil {
-		return err
+		return "", fmt.Errorf("获取 stdin 失败: %w", err)
 	}
 
 	go func() {
 		defer stdin.Close()
-		io.WriteString(stdin, content)
+		io.WriteString(stdin, script)
 	}()
 
-	// 展开 ~ 路径
-	cmd := fmt.Sprintf("cat > %s", remotePath)
-	return session.Run(cmd)
+	output, err := session.CombinedOutput(monitor.BashScriptCommand(args...))
+	return string(output), err
 }
 
-// configureHooks 配置 Claude Code hooks
-func (i *Installer) configureHooks() error {
+// runCommand 执行远程命令
+func (i *Installer) runCommand(cmd string) error {
 	session, err := i.client.NewSession()
 	if err != nil {
 		return err
 	}
 	defer session.Close()
 
-	// 使用嵌入的安装脚本配置 hooks
-	stdin, err := session.StdinPipe()
-	if err != nil {
-		return err
-	}
-
-	go func() {
-		defer stdin.Close()
-		io.WriteString(stdin, InstallRemoteScript)
-	}()
+	return session.Run(cmd)
+}
 
-	// 捕获 stderr 以便排查失败原因
-	output, err := session.CombinedOutput("bash -s")
+// configureHooks 配置 Claude Code hooks
+func (i *Installer) configureHooks() error {
+	// 按安装模式写入 settings 文件或启用插件
+	script, args := ConfigureHooksCommand(i.cfg)
+	output, err := i.RunScript(script, args...)
 	if err != nil {
-		return fmt.Errorf("%w (output: %s)", err, strings.TrimSpace(string(output)))
+		// 带上输出以便排查失败原因
+		return fmt.Errorf("%w (output: %s)", err, strings.TrimSpace(output))
 	}
 	return nil
 }
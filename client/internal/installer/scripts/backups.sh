@@ -0,0 +1,183 @@
+#!/bin/bash
+# settings.json 备份管理脚本
+# 由客户端通过 SSH/WSL 执行（bash -s -- <子命令> [参数...]）
+#
+# 管理的 settings 文件取自安装时记录的 install.conf，未记录时为
+# ${CLAUDE_CONFIG_DIR:-$HOME/.claude}/settings.json
+#
+# 子命令：
+#   list                  每行输出一个备份：<完整路径>\t<修改时间戳>\t<字节数>，按新到旧排序
+#   diff <name>           输出备份与对应 settings 文件的 unified diff
+#   restore <name>        将备份原子地恢复为对应 settings 文件（恢复前先备份当前文件）
+#   <name> 可以是完整路径，也可以是在所有目标中唯一的文件名
+#   prune [选项]          按保留策略删除旧备份
+#       --keep N          保留最新的 N 个
+#       --max-age SECONDS 保留修改时间在 SECONDS 秒以内的
+#       --dry-run         只输出将要删除的文件，不实际删除
+#     同时指定 --keep 与 --max-age 时，满足任一条件的备份都会保留
+
+set -u
+
+INSTALL_CONF="$HOME/.claude-status/install.conf"
+
+TARGETS=()
+if [ -f "$INSTALL_CONF" ]; then
+    while IFS='=' read -r key value; do
+        [ "$key" = "target" ] && TARGETS+=("$value")
+    done < "$INSTALL_CONF"
+fi
+if [ ${#TARGETS[@]} -eq 0 ]; then
+    TARGETS=("${CLAUDE_CONFIG_DIR:-$HOME/.claude}/settings.json")
+fi
+
+die() {
+    echo "Error: $*" >&2
+    exit 1
+}
+
+# 按修改时间从新到旧列出某个 settings 文件的备份（完整路径）
+list_backups() {
+    local settings="$1"
+    shopt -s nullglob
+    local files=("$settings".backup.*)
+    shopt -u nullglob
+    [ ${#files[@]} -gt 0 ] || return 0
+    ls -1t -- "${files[@]}" 2>/dev/null
+}
+
+# 将备份名解析为 "备份路径<TAB>所属 settings 文件"，同时防止越界访问
+resolve_backup() {
+    local name="$1" found="" settings=""
+    local target
+    for target in "${TARGETS[@]}"; do
+        local candidate
+        case "$name" in
+            */*) candidate="$name" ;;
+            *) candidate="$(dirname "$target")/$name" ;;
+        esac
+        case "$candidate" in
+            "$target".backup.*) ;;
+            *) continue ;;
+        esac
+        case "${candidate#"$target".backup.}" in
+            */*|*..*) continue ;;
+        esac
+        [ -f "$candidate" ] || continue
+        [ -z "$found" ] || die "ambiguous backup name, use the full path: $name"
+        found="$candidate"
+        settings="$target"
+    done
+    [ -n "$found" ] || die "backup not found: $name"
+    printf '%s\t%s' "$found" "$settings"
+}
+
+cmd_list() {
+    local target
+    for target in "${TARGETS[@]}"; do
+        list_backups "$target" | while IFS= read -r path; do
+            stat -c "%n	%Y	%s" -- "$path" 2>/dev/null
+        done
+    done
+}
+
+cmd_diff() {
+    [ $# -ge 1 ] || die "usage: diff <name>"
+    local resolved path settings
+    resolved=$(resolve_backup "$1") || exit 1
+    IFS=$'\t' read -r path settings <<< "$resolved"
+    local current="$settings"
+    [ -f "$current" ] || current=/dev/null
+    # diff 在有差异时返回 1，这里视为成功
+    diff -u --label "$path" --label "$settings" -- "$path" "$current"
+    [ $? -le 1 ]
+}
+
+cmd_restore() {
+    [ $# -ge 1 ] || die "usage: restore <name>"
+    local resolved path CLAUDE_SETTINGS
+    resolved=$(resolve_backup "$1") || exit 1
+    IFS=$'\t' read -r path CLAUDE_SETTINGS <<< "$resolved"
+
+    if command -v jq &> /dev/null && ! jq empty "$path" 2>/dev/null; then
+        die "backup is not valid JSON: $1"
+    fi
+
+    # 恢复前先备份当前文件，避免误操作无法回退
+    if [ -f "$CLAUDE_SETTINGS" ]; then
+        cp "$CLAUDE_SETTINGS" "$CLAUDE_SETTINGS.backup.restore.$(date +%Y%m%d%H%M%S)" || die "failed to back up current settings.json"
+    fi
+
+    # 先复制到同目录临时文件，再 mv 覆盖，保证原子替换
+    local tmp="$CLAUDE_SETTINGS.tmp.$$"
+    cp "$path" "$tmp" || die "failed to copy backup"
+    mkdir -p "$(dirname "$CLAUDE_SETTINGS")"
+    mv -f "$tmp" "$CLAUDE_SETTINGS" || { rm -f "$tmp"; die "failed to replace $CLAUDE_SETTINGS"; }
+    echo "restored $path -> $CLAUDE_SETTINGS"
+}
+
+cmd_prune() {
+    local keep="" max_age="" dry_run=0
+    while [ $# -gt 0 ]; do
+        case "$1" in
+            --keep|--max-age)
+                [ $# -ge 2 ] || die "$1 requires a value"
+                [ "$1" = --keep ] && keep="$2" || max_age="$2"
+                shift 2
+                ;;
+            --dry-run) dry_run=1; shift ;;
+            *) die "unknown option: $1" ;;
+        esac
+    done
+
+    [ -n "$keep" ] || [ -n "$max_age" ] || die "prune requires --keep and/or --max-age"
+    case "$keep" in ''|*[!0-9]*) [ -z "$keep" ] || die "invalid --keep: $keep" ;; esac
+    case "$max_age" in ''|*[!0-9]*) [ -z "$max_age" ] || die "invalid --max-age: $max_age" ;; esac
+
+    local now total=0 deleted=0 target
+    now=$(date +%s)
+
+    # 每个 settings 文件的备份独立计算保留数量
+    for target in "${TARGETS[@]}"; do
+        local index=0 path
+        while IFS= read -r path; do
+            local retain=0
+
+            if [ -n "$keep" ] && [ "$index" -lt "$keep" ]; then
+                retain=1
+            fi
+            if [ -n "$max_age" ]; then
+                local mtime
+                mtime=$(stat -c %Y -- "$path" 2>/dev/null || echo 0)
+                if [ $((now - mtime)) -le "$max_age" ]; then
+                    retain=1
+                fi
+            fi
+            index=$((index + 1))
+
+            [ "$retain" = "1" ] && continue
+            if [ "$dry_run" = "1" ]; then
+                echo "would delete $path"
+            else
+                rm -f -- "$path" && echo "deleted $path"
+            fi
+            deleted=$((deleted + 1))
+        done < <(list_backups "$target")
+        total=$((total + index))
+    done
+
+    if [ "$dry_run" = "1" ]; then
+        echo "would prune $deleted of $total backups"
+    else
+        echo "pruned $deleted of $total backups"
+    fi
+}
+
+sub="${1:-}"
+[ $# -gt 0 ] && shift
+case "$sub" in
+    list) cmd_list ;;
+    diff) cmd_diff "$@" ;;
+    restore) cmd_restore "$@" ;;
+    prune) cmd_prune "$@" ;;
+    *) die "usage: backups.sh list|diff <name>|restore <name>|prune [--keep N] [--max-age SECONDS] [--dry-run]" ;;
+esac
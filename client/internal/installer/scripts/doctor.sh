@@ -0,0 +1,162 @@
+#!/bin/bash
+# 服务端诊断脚本
+# 由客户端通过 SSH/WSL 执行，输出人类可读的诊断信息：
+#   - 已安装脚本版本与依赖
+#   - 守护进程状态（PID、版本、连接数）与事件日志大小
+#   - 服务端通知配置与客户端租约
+#   - 安装时记录的范围（install.conf）
+#   - 各候选 settings 文件中 status-hook 的启用情况（即哪些范围处于生效状态）
+
+set -u
+
+STATUS_DIR="$HOME/.claude-status"
+INSTALL_CONF="$STATUS_DIR/install.conf"
+USER_SETTINGS="${CLAUDE_CONFIG_DIR:-$HOME/.claude}/settings.json"
+
+echo "== 服务端 =="
+
+installed="未安装"
+if [ -f "$STATUS_DIR/monitor.sh" ]; then
+    installed=$(sed -n 's/^SCRIPT_VERSION="\(.*\)"$/\1/p' "$STATUS_DIR/monitor.sh" | head -1)
+fi
+echo "脚本版本: $installed"
+
+for dep in inotifywait jq; do
+    if command -v "$dep" &> /dev/null; then
+        echo "依赖 $dep: ok"
+    else
+        echo "依赖 $dep: 缺失"
+    fi
+done
+if [ -s "$STATUS_DIR/notify.conf" ]; then
+    # 服务端通知由守护进程在客户端断开后推送，没有守护进程时通知不会发送
+    for dep in socat flock setsid; do
+        if command -v "$dep" &> /dev/null; then
+            echo "依赖 $dep: ok"
+        else
+            echo "依赖 $dep: 缺失（已配置服务端通知，没有守护进程时通知不会发送）"
+        fi
+    done
+elif command -v socat &> /dev/null; then
+    echo "可选依赖 socat: ok"
+else
+    echo "可选依赖 socat: 缺失（每个连接各自监听，不使用守护进程）"
+fi
+
+DAEMON_DIR="$STATUS_DIR/.daemon"
+daemon_pid=$(cat "$DAEMON_DIR/pid" 2>/dev/null || true)
+if [ -n "$daemon_pid" ] && kill -0 "$daemon_pid" 2>/dev/null; then
+    daemon_clients=$(find "$DAEMON_DIR/clients" -mindepth 1 -maxdepth 1 -type d 2>/dev/null | wc -l)
+    echo "守护进程: 运行中 (pid $daemon_pid, 版本 $(cat "$DAEMON_DIR/version" 2>/dev/null || echo 未知), $daemon_clients 个连接)"
+else
+    echo "守护进程: 未运行"
+fi
+if [ -s "$STATUS_DIR/notify.conf" ]; then
+    methods=$(sed -n 's/^\(webhook\|smtp_url\|command\)=.*/\1/p' "$STATUS_DIR/notify.conf" | sed 's/smtp_url/smtp/' | tr '\n' ' ')
+    echo "服务端通知: ${methods:-无} (events: $(sed -n 's/^events=//p' "$STATUS_DIR/notify.conf"))"
+    lease=$(cat "$STATUS_DIR/lease" 2>/dev/null || echo 0)
+    if [ "${lease:-0}" -gt "$(date +%s)" ] 2>/dev/null; then
+        echo "客户端租约: 有效，剩余 $((lease - $(date +%s))) 秒"
+    else
+        echo "客户端租约: 无（状态变化将由服务端推送）"
+    fi
+    if ! command -v curl &> /dev/null && grep -qE '^(webhook|smtp_url)=' "$STATUS_DIR/notify.conf"; then
+        echo "依赖 curl: 缺失（webhook 与 SMTP 通知不可用）"
+    fi
+else
+    echo "服务端通知: 未配置"
+fi
+if [ -s "$STATUS_DIR/retention.conf" ]; then
+    echo "保留策略: $(tr '\n' ' ' < "$STATUS_DIR/retention.conf")（秒）"
+else
+    echo "保留策略: 默认（idle=3600 stopped=3600 archive=0）"
+fi
+if [ -s "$STATUS_DIR/history/sessions.jsonl" ]; then
+    echo "会话归档: $(cat "$STATUS_DIR"/history/sessions.jsonl* 2>/dev/null | wc -l) 个会话, $(du -sh "$STATUS_DIR/history" 2>/dev/null | cut -f1)"
+fi
+if [ -s "$STATUS_DIR/journal/seq" ]; then
+    echo "事件日志: $(cat "$STATUS_DIR/journal/seq") 个事件, $(du -sh "$STATUS_DIR/journal" 2>/dev/null | cut -f1)"
+fi
+
+if [ -n "${CLAUDE_CONFIG_DIR:-}" ]; then
+    echo "CLAUDE_CONFIG_DIR: $CLAUDE_CONFIG_DIR"
+fi
+
+# 收集候选文件：install.conf 记录的目标 + user 范围默认文件
+recorded_mode="settings"
+recorded_scope="(无记录)"
+targets=()
+plugin_dirs=()
+if [ -f "$INSTALL_CONF" ]; then
+    while IFS='=' read -r key value; do
+        case "$key" in
+            mode) recorded_mode="$value" ;;
+            dir) plugin_dirs+=("$value") ;;
+            scope) recorded_scope="$value" ;;
+            target) targets+=("$value") ;;
+        esac
+    done < "$INSTALL_CONF"
+fi
+echo "安装模式: $recorded_mode"
+echo "安装范围: $recorded_scope"
+
+if [ "$recorded_mode" = "plugin" ]; then
+    echo
+    echo "== 插件 =="
+    for d in "${plugin_dirs[@]}"; do
+        echo "启用目录: $d"
+    done
+    if [ -x "$STATUS_DIR/plugin.sh" ]; then
+        "$STATUS_DIR/plugin.sh" status 2>&1
+    else
+        echo "plugin.sh 缺失，请重新安装"
+    fi
+fi
+
+seen_user=0
+for t in "${targets[@]}"; do
+    [ "$t" = "$USER_SETTINGS" ] && seen_user=1
+done
+[ "$seen_user" = "1" ] || targets+=("$USER_SETTINGS")
+
+# scope_of 根据路径推断范围
+scope_of() {
+    case "$1" in
+        "$USER_SETTINGS") echo "user" ;;
+        */.claude/settings.local.json) echo "local" ;;
+        */.claude/settings.json) echo "project" ;;
+        *) echo "config_dir" ;;
+    esac
+}
+
+echo
+echo "== Hook 范围 =="
+active=0
+for t in "${targets[@]}"; do
+    scope=$(scope_of "$t")
+    if [ ! -f "$t" ]; then
+        echo "[$scope] $t: 文件不存在"
+        continue
+    fi
+    if ! command -v jq &> /dev/null; then
+        if grep -q "status-hook.sh" "$t" 2>/dev/null; then
+            echo "[$scope] $t: 生效"
+            active=$((active + 1))
+        else
+            echo "[$scope] $t: 未安装"
+        fi
+        continue
+    fi
+    count=$(jq '[.hooks // {} | .[] | .[] | select(.hooks[0].command // "" | contains("status-hook.sh"))] | length' "$t" 2>/dev/null)
+    if [ -z "$count" ]; then
+        echo "[$scope] $t: JSON 无效"
+    elif [ "$count" -gt 0 ]; then
+        echo "[$scope] $t: 生效（$count 个 Hook）"
+        active=$((active + 1))
+    else
+        echo "[$scope] $t: 未安装"
+    fi
+done
+
+echo
+echo "生效范围数: $active"
@@ -1,62 +1,131 @@
 #!/bin/bash
-# 远程安装脚本 - 配置 Claude Code Hooks
+# 远程安装脚本 - 配置 Claude Code Hooks（settings 模式）
 # 由客户端通过 SSH 执行
+#
+# 用法: install-remote.sh [--scope <scope>] [settings.json 路径...]
+#   未指定路径时写入 ${CLAUDE_CONFIG_DIR:-$HOME/.claude}/settings.json（user 范围）
+#   路径可使用 ~ 前缀，由本脚本展开
+#
+# Hook 事件映射取自客户端上传的 ~/.claude-status/hooks/hooks.json。
+# 安装完成后将模式、范围与目标文件记录到 ~/.claude-status/install.conf，
+# 供 monitor.sh 断开清理、卸载脚本和 doctor 使用
 
 set -e
 
 HOOK_CMD="$HOME/.claude-status/hooks/status-hook.sh"
-CLAUDE_SETTINGS="$HOME/.claude/settings.json"
+HOOKS_TEMPLATE="$HOME/.claude-status/hooks/hooks.json"
+STATUS_DIR="$HOME/.claude-status"
+INSTALL_CONF="$STATUS_DIR/install.conf"
 
-# 确保目录和 settings.json 存在
-mkdir -p "$(dirname "$CLAUDE_SETTINGS")"
-if [ ! -f "$CLAUDE_SETTINGS" ]; then
-    echo '{}' > "$CLAUDE_SETTINGS"
+SCOPE="user"
+TARGETS=()
+while [ $# -gt 0 ]; do
+    case "$1" in
+        --scope) SCOPE="$2"; shift 2 ;;
+        *) TARGETS+=("${1/#\~/$HOME}"); shift ;;
+    esac
+done
+
+if [ ${#TARGETS[@]} -eq 0 ]; then
+    TARGETS=("${CLAUDE_CONFIG_DIR:-$HOME/.claude}/settings.json")
+fi
+
+if [ ! -f "$HOOKS_TEMPLATE" ]; then
+    echo "Error: $HOOKS_TEMPLATE not found" >&2
+    exit 1
 fi
 
-# 验证 settings.json 是有效 JSON
-if ! jq empty "$CLAUDE_SETTINGS" 2>/dev/null; then
-    echo "Error: $CLAUDE_SETTINGS is not valid JSON, resetting to {}" >&2
-    echo '{}' > "$CLAUDE_SETTINGS"
+# jq 公共定义：移除 status-hook.sh 相关 Hook，并清理空数组与空 hooks 对象
+JQ_REMOVE='
+    def remove_status_hooks:
+        if .hooks then
+            .hooks |= with_entries(.value |= map(select(.hooks[0].command | contains("status-hook.sh") | not))) |
+            .hooks |= with_entries(select(.value != [])) |
+            if .hooks == {} then del(.hooks) else . end
+        else . end;
+'
+
+# configure_settings 向单个 settings 文件写入 Hook 配置
+configure_settings() {
+    local CLAUDE_SETTINGS="$1"
+
+    # 确保目录和 settings.json 存在
+    mkdir -p "$(dirname "$CLAUDE_SETTINGS")"
+    if [ ! -f "$CLAUDE_SETTINGS" ]; then
+        echo '{}' > "$CLAUDE_SETTINGS"
+    fi
+
+    # 验证 settings.json 是有效 JSON
+    if ! jq empty "$CLAUDE_SETTINGS" 2>/dev/null; then
+        echo "Error: $CLAUDE_SETTINGS is not valid JSON, resetting to {}" >&2
+        echo '{}' > "$CLAUDE_SETTINGS"
+    fi
+
+    # 备份
+    cp "$CLAUDE_SETTINGS" "$CLAUDE_SETTINGS.backup.$(date +%Y%m%d%H%M%S)" 2>/dev/null || true
+
+    # 先删除旧的 claude-status Hook（如果存在），再按模板追加新的
+    # 这样可以确保版本更新时 Hook 配置也被更新
+    jq --arg hook "$HOOK_CMD" --slurpfile tpl "$HOOKS_TEMPLATE" "$JQ_REMOVE"'
+        ($tpl[0] | map_values(map(.hooks |= map(.command |= sub("__HOOK_CMD__"; $hook))))) as $new |
+        remove_status_hooks |
+        .hooks = reduce ($new | keys_unsorted[]) as $event (.hooks // {};
+            .[$event] = ((.[$event] // []) + $new[$event]))
+    ' "$CLAUDE_SETTINGS" > "$CLAUDE_SETTINGS.tmp" && mv "$CLAUDE_SETTINGS.tmp" "$CLAUDE_SETTINGS"
+}
+
+# remove_settings_hooks 从不再使用的 settings 文件中移除 status-hook.sh 相关 Hook
+remove_settings_hooks() {
+    local CLAUDE_SETTINGS="$1"
+    [ -f "$CLAUDE_SETTINGS" ] || return 0
+    cp "$CLAUDE_SETTINGS" "$CLAUDE_SETTINGS.backup.$(date +%Y%m%d%H%M%S)" 2>/dev/null || true
+    jq "$JQ_REMOVE"' remove_status_hooks' "$CLAUDE_SETTINGS" > "$CLAUDE_SETTINGS.tmp" && mv "$CLAUDE_SETTINGS.tmp" "$CLAUDE_SETTINGS"
+}
+
+# 从插件模式切换过来时，先停用插件，避免 Hook 重复触发
+if [ -f "$INSTALL_CONF" ] && grep -qx 'mode=plugin' "$INSTALL_CONF"; then
+    if [ -x "$STATUS_DIR/plugin.sh" ]; then
+        "$STATUS_DIR/plugin.sh" uninstall || echo "Warning: failed to disable plugin" >&2
+    fi
+fi
+
+# project/local 范围要求仓库已存在，避免在错误路径下凭空创建 .claude 目录
+for target in "${TARGETS[@]}"; do
+    if [ "$SCOPE" = "project" ] || [ "$SCOPE" = "local" ]; then
+        repo_dir="$(dirname "$(dirname "$target")")"
+        if [ ! -d "$repo_dir" ]; then
+            echo "Error: repository not found: $repo_dir" >&2
+            exit 1
+        fi
+    fi
+    configure_settings "$target"
+    echo "Hook 已写入: $target"
+done
+
+# 切换范围时，清理上次安装但本次不再使用的目标，避免残留 Hook
+if [ -f "$INSTALL_CONF" ]; then
+    while IFS='=' read -r key value; do
+        [ "$key" = "target" ] || continue
+        keep=0
+        for target in "${TARGETS[@]}"; do
+            if [ "$target" = "$value" ]; then
+                keep=1
+            fi
+        done
+        if [ "$keep" = "0" ]; then
+            remove_settings_hooks "$value" && echo "Hook 已移除: $value"
+        fi
+    done < "$INSTALL_CONF"
 fi
 
-# 备份
-cp "$CLAUDE_SETTINGS" "$CLAUDE_SETTINGS.backup.$(date +%Y%m%d%H%M%S)" 2>/dev/null || true
-
-# 先删除旧的 claude-status Hook（如果存在），再添加新的
-# 这样可以确保版本更新时 Hook 配置也被更新
-jq --arg hook "$HOOK_CMD" '
-    def remove_old_hooks:
-        if . == null then []
-        else [.[] | select(.hooks[0].command | contains("status-hook.sh") | not)]
-        end;
-
-    .hooks.UserPromptSubmit = (.hooks.UserPromptSubmit | remove_old_hooks) |
-    .hooks.PostToolUse = (.hooks.PostToolUse | remove_old_hooks) |
-    .hooks.Stop = (.hooks.Stop | remove_old_hooks) |
-    .hooks.PermissionRequest = (.hooks.PermissionRequest | remove_old_hooks) |
-    .hooks.SessionStart = (.hooks.SessionStart | remove_old_hooks) |
-    .hooks.SessionEnd = (.hooks.SessionEnd | remove_old_hooks) |
-
-    .hooks.UserPromptSubmit = (.hooks.UserPromptSubmit // []) + [{
-        "hooks": [{"type": "command", "command": ($hook + " working")}]
-    }] |
-    .hooks.PostToolUse = (.hooks.PostToolUse // []) + [{
-        "matcher": "*",
-        "hooks": [{"type": "command", "command": ($hook + " working")}]
-    }] |
-    .hooks.Stop = (.hooks.Stop // []) + [{
-        "hooks": [{"type": "command", "command": ($hook + " idle")}]
-    }] |
-    .hooks.PermissionRequest = (.hooks.PermissionRequest // []) + [{
-        "matcher": "*",
-        "hooks": [{"type": "command", "command": ($hook + " idle")}]
-    }] |
-    .hooks.SessionStart = (.hooks.SessionStart // []) + [{
-        "hooks": [{"type": "command", "command": ($hook + " idle")}]
-    }] |
-    .hooks.SessionEnd = (.hooks.SessionEnd // []) + [{
-        "hooks": [{"type": "command", "command": ($hook + " stopped")}]
-    }]
-' "$CLAUDE_SETTINGS" > "$CLAUDE_SETTINGS.tmp" && mv "$CLAUDE_SETTINGS.tmp" "$CLAUDE_SETTINGS"
+# 记录安装模式、范围与目标文件
+mkdir -p "$STATUS_DIR"
+{
+    echo "mode=settings"
+    echo "scope=$SCOPE"
+    for target in "${TARGETS[@]}"; do
+        echo "target=$target"
+    done
+} > "$INSTALL_CONF"
 
 echo "Hook 配置完成"
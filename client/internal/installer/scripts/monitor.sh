@@ -5,58 +5,88 @@
 # 供 SSH 客户端读取
 #
 # 依赖: inotify-tools (sudo apt install inotify-tools)
+# 可选: socat（多个连接共用一个守护进程，缺失时每个连接各自监听）
 
 SCRIPT_VERSION="__VERSION__"
 STATUS_DIR="$HOME/.claude-status"
-CLAUDE_SETTINGS="$HOME/.claude/settings.json"
+INSTALL_CONF="$STATUS_DIR/install.conf"
 
-# 清理函数：连接断开时移除 Hook 并清理状态文件
+# 输出安装时记录的 settings 目标文件（每行一个），未记录时为 user 范围默认路径
+# 插件模式不修改 settings 文件，不输出任何目标
+settings_targets() {
+    if [ -f "$INSTALL_CONF" ] && grep -qx 'mode=plugin' "$INSTALL_CONF"; then
+        return 0
+    elif [ -f "$INSTALL_CONF" ] && grep -q '^target=' "$INSTALL_CONF"; then
+        sed -n 's/^target=//p' "$INSTALL_CONF"
+    else
+        echo "${CLAUDE_CONFIG_DIR:-$HOME/.claude}/settings.json"
+    fi
+}
+
+# 清理函数：连接断开（守护进程模式下为守护进程退出）时移除 Hook 并清理状态文件
 cleanup() {
     echo "[cleanup] Connection closed, removing hooks and status files..." >&2
 
-    # 移除 Claude Code settings 中与 status-hook.sh 相关的 Hook
+    # 移除各安装目标中与 status-hook.sh 相关的 Hook
+    while IFS= read -r CLAUDE_SETTINGS; do
+        remove_hooks "$CLAUDE_SETTINGS"
+    done < <(settings_targets)
+
+    # 清理状态文件
+    rm -f "$STATUS_DIR"/*.json 2>/dev/null
+    echo "[cleanup] Status files cleaned up" >&2
+
+    # 停止控制通道
+    [ -n "$CONTROL_READER" ] && kill "$CONTROL_READER" 2>/dev/null
+    [ "$MODE" = "standalone" ] && rm -rf "$CLIENTS_DIR/$$" 2>/dev/null
+}
+
+# 移除单个 settings 文件中与 status-hook.sh 相关的 Hook
+remove_hooks() {
+    local CLAUDE_SETTINGS="$1"
     if [ -f "$CLAUDE_SETTINGS" ] && command -v jq &> /dev/null; then
+        # 按事件通用处理，覆盖 hooks.json 中的全部事件
         jq '
-            def remove_status_hooks:
-                if . == null then null
-                elif (. | length) == 0 then .
-                else [.[] | select(.hooks[0].command | contains("status-hook.sh") | not)]
-                end;
-
             if .hooks then
-                .hooks.UserPromptSubmit = (.hooks.UserPromptSubmit | remove_status_hooks) |
-                .hooks.PostToolUse = (.hooks.PostToolUse | remove_status_hooks) |
-                .hooks.Stop = (.hooks.Stop | remove_status_hooks) |
-                .hooks.PermissionRequest = (.hooks.PermissionRequest | remove_status_hooks) |
-                .hooks.SessionStart = (.hooks.SessionStart | remove_status_hooks) |
-                .hooks.SessionEnd = (.hooks.SessionEnd | remove_status_hooks) |
-
+                .hooks |= with_entries(.value |= map(select(.hooks[0].command | contains("status-hook.sh") | not))) |
                 # 清理空数组的 key
-                if .hooks.UserPromptSubmit == [] then del(.hooks.UserPromptSubmit) else . end |
-                if .hooks.PostToolUse == [] then del(.hooks.PostToolUse) else . end |
-                if .hooks.Stop == [] then del(.hooks.Stop) else . end |
-                if .hooks.PermissionRequest == [] then del(.hooks.PermissionRequest) else . end |
-                if .hooks.SessionStart == [] then del(.hooks.SessionStart) else . end |
-                if .hooks.SessionEnd == [] then del(.hooks.SessionEnd) else . end |
-
+                .hooks |= with_entries(select(.value != [])) |
                 # 如果 hooks 对象为空则也删除
                 if .hooks == {} then del(.hooks) else . end
             else .
             end
         ' "$CLAUDE_SETTINGS" > "$CLAUDE_SETTINGS.tmp" 2>/dev/null && mv -f "$CLAUDE_SETTINGS.tmp" "$CLAUDE_SETTINGS"
-        echo "[cleanup] Hooks removed from settings.json" >&2
+        echo "[cleanup] Hooks removed from $CLAUDE_SETTINGS" >&2
     fi
-
-    # 清理状态文件
-    rm -f "$STATUS_DIR"/*.json 2>/dev/null
-    echo "[cleanup] Status files cleaned up" >&2
 }
 
-# 捕获退出信号，执行清理
-trap cleanup EXIT HUP TERM INT
+# 参数解析
+#   --once          输出一次版本与状态后立即退出（不清理 Hook、不监听变化），供客户端 status 命令使用
+#   --standalone    本连接独立监听状态文件，断开时移除 Hook（服务端缺少 socat 时的默认方式）
+#   --ensure-daemon 确保守护进程在运行，输出其 Unix socket 路径后退出，供客户端经 SSH direct-streamlocal 直连
+#   --daemon        （内部）守护进程，由 ensure_daemon 在后台启动
+#   --serve-client  （内部）由守护进程的 socat 监听器为每个 socket 连接启动
+# 无参数时（包括受限密钥的 forced command）确保守护进程在运行，并把 stdin/stdout 桥接到其 socket
+MODE=""
+for arg in "$@"; do
+    case "$arg" in
+        --once) MODE=once ;;
+        --standalone) MODE=standalone ;;
+        --ensure-daemon) MODE=ensure ;;
+        --daemon) MODE=daemon ;;
+        --serve-client) MODE=serve ;;
+    esac
+done
+if [ -z "$MODE" ]; then
+    if command -v socat &> /dev/null && command -v flock &> /dev/null && command -v setsid &> /dev/null; then
+        MODE=bridge
+    else
+        MODE=standalone
+    fi
+fi
 
 # 检查 inotifywait 是否可用
-if ! command -v inotifywait &> /dev/null; then
+if [ "$MODE" != "once" ] && ! command -v inotifywait &> /dev/null; then
     echo '{"type":"error","message":"inotifywait not found. Please install: sudo apt install inotify-tools"}'
     exit 1
 fi
@@ -64,11 +94,694 @@ fi
 # 确保状态目录存在
 mkdir -p "$STATUS_DIR"
 
+# 守护进程：每个用户一个，独占 inotifywait 监听与会话状态，经 Unix socket 服务任意数量的连接。
+# 按需启动，最后一个连接断开 DAEMON_IDLE_TIMEOUT 秒后退出，退出时才移除 Hook 与状态文件，
+# 避免多个连接各自监听、断开时互相移除 Hook。每个连接在 CLIENTS_DIR 下有一个以处理进程 PID
+# 命名的目录：queue 为该连接发来的控制命令，out 为守护进程待发送的消息，subscribe 为订阅过滤
+DAEMON_DIR="$STATUS_DIR/.daemon"
+DAEMON_SOCKET="$DAEMON_DIR/monitor.sock"
+DAEMON_IDLE_TIMEOUT="${CLAUDE_STATUS_IDLE_TIMEOUT:-300}"
+DAEMON_LOG_MAX=1048576
+CLIENT_OUT_MAX=4194304
+SCRIPT_PATH="$(readlink -f "${BASH_SOURCE[0]}")"
+if [ "$MODE" = "standalone" ] || [ "$MODE" = "once" ]; then
+    CLIENTS_DIR="$STATUS_DIR/.control"
+else
+    CLIENTS_DIR="$DAEMON_DIR/clients"
+fi
+
+# 确保守护进程在运行且与本脚本版本一致：旧版本守护进程收到 USR1 后退出但保留 Hook 与状态文件，
+# 由新版本接管。需要时以 setsid 在后台启动（脱离当前 SSH 会话），等待 socket 就绪；失败返回 1
+ensure_daemon() {
+    local pid i
+    mkdir -p "$DAEMON_DIR" || return 1
+    exec 9> "$DAEMON_DIR/start.lock"
+    flock 9
+
+    pid=$(cat "$DAEMON_DIR/pid" 2>/dev/null)
+    if [ -n "$pid" ] && kill -0 "$pid" 2>/dev/null; then
+        if [ "$(cat "$DAEMON_DIR/version" 2>/dev/null)" = "$SCRIPT_VERSION" ] && [ -S "$DAEMON_SOCKET" ]; then
+            exec 9>&-
+            return 0
+        fi
+        echo "[daemon] Replacing daemon $pid ($(cat "$DAEMON_DIR/version" 2>/dev/null))" >&2
+        kill -USR1 "$pid" 2>/dev/null
+        for i in {1..150}; do
+            kill -0 "$pid" 2>/dev/null || break
+            sleep 0.1
+        done
+    fi
+
+    rm -f "$DAEMON_SOCKET"
+    setsid bash "$SCRIPT_PATH" --daemon < /dev/null > /dev/null 2>> "$DAEMON_DIR/daemon.log" 9>&- &
+    for i in {1..50}; do
+        [ -S "$DAEMON_SOCKET" ] && break
+        sleep 0.1
+    done
+    exec 9>&-
+    [ -S "$DAEMON_SOCKET" ]
+}
+
+# 把连接目录中 out 的内容发送到 stdout：加锁改名后发送，守护进程随后的写入进入新的 out
+drain_out() {
+    local dir="$1"
+    [ -s "$dir/out" ] || return 0
+    { flock 9 && mv -f "$dir/out" "$dir/sending"; } 9> "$dir/lock" 2>/dev/null || return 0
+    cat "$dir/sending" || return 1
+    rm -f "$dir/sending"
+}
+
+# 服务一个 socket 连接（stdin/stdout 即连接）：先发送守护进程的版本，再在后台转发守护进程写入 out 的
+# 消息（inotify 触发，另每秒检查一次）；收到的控制命令追加到 queue。连接关闭时删除连接目录，
+# 后台的 inotifywait 随目录删除退出
+serve_client() {
+    local dir="$CLIENTS_DIR/$$" line name
+    mkdir -p "$dir" && : > "$dir/queue" || exit 1
+    printf '{"type":"version","version":"%s"}\n' "$(cat "$DAEMON_DIR/version" 2>/dev/null)"
+
+    inotifywait -m -q -e close_write --format '%f' "$dir" 2>/dev/null | while :; do
+        IFS= read -r -t 1 name
+        [ $? -eq 1 ] && break
+        drain_out "$dir" || break
+    done &
+
+    while IFS= read -r line; do
+        printf '%s\n' "$line" >> "$dir/queue"
+    done
+    rm -rf "$dir"
+}
+
+case "$MODE" in
+    ensure)
+        ensure_daemon || { echo "Error: 守护进程启动失败，详见 $DAEMON_DIR/daemon.log" >&2; exit 1; }
+        echo "$DAEMON_SOCKET"
+        exit 0
+        ;;
+    serve)
+        serve_client
+        exit 0
+        ;;
+    bridge)
+        # 连接失败（守护进程恰好因空闲退出）时重新启动，多次失败后改为独立监听
+        for attempt in 1 2 3; do
+            ensure_daemon || break
+            socat - UNIX-CONNECT:"$DAEMON_SOCKET" 2>/dev/null && exit 0
+        done
+        echo "[bridge] Daemon unavailable, falling back to standalone mode" >&2
+        MODE=standalone
+        CLIENTS_DIR="$STATUS_DIR/.control"
+        ;;
+esac
+
+# 捕获退出信号，执行清理（守护进程的清理见 start_daemon）
+if [ "$MODE" = "standalone" ]; then
+    trap cleanup EXIT HUP TERM INT
+fi
+
+# 保留策略，由 set-retention 修改并写入 RETENTION_CONF（各实例与守护进程共用，重启后保留），
+# 启动时与每 CLEANUP_INTERVAL 秒按策略清理一次：
+#   RETENTION_SECONDS 未结束的会话超过该时长未更新且进程已不在时清理
+#   STOPPED_RETENTION 已结束（stopped）的会话超过该时长未更新即清理
+#   RETENTION_ARCHIVE 为 1 时清理前把会话（含 Token 用量）归档到 HISTORY_DIR，而不是直接删除
+RETENTION_CONF="$STATUS_DIR/retention.conf"
+RETENTION_SECONDS=3600
+STOPPED_RETENTION=3600
+RETENTION_ARCHIVE=0
+CLEANUP_INTERVAL=60
+
+# 读取 RETENTION_CONF（每行 key=value：idle、stopped 为秒数，archive 为 0 或 1），无效值忽略
+load_retention_conf() {
+    local key value
+    [ -f "$RETENTION_CONF" ] || return 0
+    while IFS='=' read -r key value; do
+        case "$key" in
+            idle) [[ "$value" =~ ^[0-9]+$ ]] && [ "$value" -ge 60 ] && RETENTION_SECONDS="$value" ;;
+            stopped) [[ "$value" =~ ^[0-9]+$ ]] && [ "$value" -ge 60 ] && STOPPED_RETENTION="$value" ;;
+            archive) [[ "$value" =~ ^[01]$ ]] && RETENTION_ARCHIVE="$value" ;;
+        esac
+    done < "$RETENTION_CONF"
+}
+
+# 控制通道：客户端经 stdin 逐行发送命令 "<id> <command> [args...]"（参数以空格分隔）。
+# 独立模式下由后台读取进程、守护进程模式下由连接的处理进程把命令追加到该连接的 queue，
+# 主循环与状态文件一起监听，处理后向该连接回复
+#   {"type":"response","id":<id>,"command":"<command>","ok":true|false,"message":"...","now":<服务端时间>}
+# 命令：
+#   ping                    立即回复，客户端据此计算往返延迟
+#   resync                  重新扫描并输出完整状态与用量窗口
+#   subscribe [project...]  本连接只接收项目目录等于或位于 project 之下、或项目名等于 project 的会话；无参数时取消过滤
+#   set-retention <idle> [stopped [archive]]
+#                           修改保留策略：未结束与已结束会话的保留时长（秒，至少 60，stopped 默认同 idle）、
+#                           是否归档（0 或 1，默认 0），写入 RETENTION_CONF 后立即清理（对所有连接生效），
+#                           回复 "<idle> <stopped> <archive>"
+#   set-debounce <ms>       修改状态变化的合并窗口（0 到 5000 毫秒，0 关闭，对所有连接生效）
+#   delete-session <id>     删除会话的状态文件与用量缓存；只来自会话记录的会话不再推断
+#   lease <seconds>         客户端租约：seconds 秒内（10 到 3600）不发送服务端通知，回复到期时间
+#   replay [journal seq]    补发事件日志 journal 中序号大于 seq 的事件（日志 ID 不同时补发全部保留的事件），
+#                           回复 "<日志 ID> <序号>"，此后的事件实时发送；无参数时只回复当前位置
+#   reply <session> <pane> <action> [text]
+#                           经 reply.sh 向会话的 tmux 窗格发送回复（校验会话状态、窗格与终端），
+#                           text 为 base64 编码的一行文字（参数不能包含空白）
+declare -A DONE # 各连接已处理的控制命令行数，键为连接 ID（独立模式下只有本进程）
+declare -A POS  # 各连接已发送的事件日志序号
+CLIENT=""       # 当前输出的连接
+DEBOUNCE_MS=200 # 状态变化的合并窗口（毫秒），可由 set-debounce 修改
+SNAPSHOTS=0     # 本统计周期内的完整状态输出次数
+SNAPSHOT_AT=""  # 最近一次输出开始读取状态文件的时间（秒，含小数）
+CONTROL_READER=""
+STATE_CHANGED=0
+SUBSCRIBE=()
+DISMISSED=" "
+[ "$MODE" = "daemon" ] || DONE[$$]=0
+
+# 输出一条消息：守护进程模式下追加到当前连接的 out（由其处理进程发送），否则写入 stdout
+emit() {
+    if [ "$MODE" = "daemon" ]; then
+        local dir="$CLIENTS_DIR/$CLIENT"
+        [ -d "$dir" ] || return 0
+        { flock 9 && printf '%s\n' "$1" >> "$dir/out"; } 9> "$dir/lock" 2>/dev/null
+    else
+        printf '%s\n' "$1"
+    fi
+}
+
+# 载入当前连接的订阅过滤
+load_subscription() {
+    SUBSCRIBE=()
+    [ -f "$CLIENTS_DIR/$CLIENT/subscribe" ] && mapfile -t SUBSCRIBE < "$CLIENTS_DIR/$CLIENT/subscribe"
+}
+
+# 向所有连接输出状态（按各自的订阅过滤），之前先记录并发送会话状态变化事件
+publish_status() {
+    SNAPSHOTS=$((SNAPSHOTS + 1))
+    SNAPSHOT_AT=$(date +%s.%N)
+    [ "$MODE" = "once" ] || record_transitions
+    for CLIENT in "${!DONE[@]}"; do
+        load_subscription
+        send_events false
+        output_status
+    done
+}
+
+# 向所有连接输出当前用量窗口
+publish_block() {
+    for CLIENT in "${!DONE[@]}"; do
+        emit "$USAGE_BLOCK"
+    done
+}
+
+# 事件日志：会话新出现或状态变化时追加到 JOURNAL_DIR/journal.log，每行一个事件
+#   {"seq":<序号>,"time":<时间>,"session_id":"...","project":"...","project_name":"...","from":"<旧状态>","to":"<新状态>"}
+# 序号单调递增（保存在 seq），超过 JOURNAL_MAX 字节时轮转为 journal.log.1..JOURNAL_KEEP，
+# 磁盘占用不超过约 (JOURNAL_KEEP+1)*JOURNAL_MAX。日志不随连接断开清理，服务器重启后保留；
+# id 在日志首次创建时生成，客户端据此判断序号是否属于同一日志。state 保存上次记录时各会话的状态，
+# 多个独立模式实例在 lock 下比较，同一变化只记录一次。连接经 POS 从日志读取事件，不论由哪个实例记录
+JOURNAL_DIR="$STATUS_DIR/journal"
+JOURNAL_MAX=262144
+JOURNAL_KEEP=3
+
+# 输出事件日志 ID，不存在时生成
+journal_id() {
+    [ -s "$JOURNAL_DIR/id" ] || { mkdir -p "$JOURNAL_DIR" && printf '%x%04x\n' "$(date +%s)" "$RANDOM" > "$JOURNAL_DIR/id"; }
+    cat "$JOURNAL_DIR/id" 2>/dev/null
+}
+
+# 输出事件日志的当前序号
+journal_seq() {
+    local seq
+    seq=$(cat "$JOURNAL_DIR/seq" 2>/dev/null)
+    [[ "$seq" =~ ^[0-9]+$ ]] && echo "$seq" || echo 0
+}
+
+# 按时间顺序输出序号大于 $1 的事件（按 $2 限制为不超过该序号）
+journal_since() {
+    local from="$1" to="${2:-}" file i last
+    for ((i = JOURNAL_KEEP; i >= 0; i--)); do
+        file="$JOURNAL_DIR/journal.log"
+        [ "$i" -gt 0 ] && file="$file.$i"
+        [ -s "$file" ] || continue
+        last=$(tail -n 1 "$file" | sed -n 's/^{"seq":\([0-9]*\),.*/\1/p')
+        [ "${last:-0}" -gt "$from" ] || continue
+        awk -v from="$from" -v to="$to" '
+            match($0, /^\{"seq":[0-9]+,/) {
+                seq = substr($0, 8, RLENGTH - 8) + 0
+                if (seq > from && (to == "" || seq <= to + 0)) print
+            }' "$file"
+    done
+}
+
+# 比较各会话的当前状态与 state，记录变化（已消失的会话只从 state 中移除）
+record_transitions() {
+    local file content session status project name line seq now
+    local re_status='"status"[[:space:]]*:[[:space:]]*"([^"]*)"'
+    # 项目路径与名称按 JSON 字符串整体匹配（含 \" 等转义），原样写入事件行
+    local re_project='"project"[[:space:]]*:[[:space:]]*"(([^"\\]|\\.)*)"'
+    local re_name='"project_name"[[:space:]]*:[[:space:]]*"(([^"\\]|\\.)*)"'
+    local -A previous
+    local current=() events=""
+
+    for file in "$STATUS_DIR"/*.json; do
+        [ -f "$file" ] || continue
+        content=$(tr -d '\n' < "$file" 2>/dev/null)
+        session="${file##*/}"
+        current+=("${session%.json}"$'\t'"$content")
+    done
+    while IFS=$'\t' read -r session content; do
+        [ -n "$session" ] && [ ! -f "$STATUS_DIR/$session.json" ] && current+=("$session"$'\t'"$content")
+    done <<< "$TRANSCRIPT_DATA"
+
+    mkdir -p "$JOURNAL_DIR" || return 1
+    journal_id > /dev/null
+    {
+        flock 6
+        [ -f "$JOURNAL_DIR/state" ] && while IFS=$'\t' read -r session status; do
+            [ -n "$session" ] && previous[$session]="$status"
+        done < "$JOURNAL_DIR/state"
+
+        seq=$(journal_seq)
+        printf -v now '%(%s)T' -1
+        : > "$JOURNAL_DIR/state.tmp"
+        for line in "${current[@]}"; do
+            session="${line%%$'\t'*}"
+            content="${line#*$'\t'}"
+            status=""; project=""; name=""
+            [[ "$content" =~ $re_status ]] && status="${BASH_REMATCH[1]}"
+            [[ "$content" =~ $re_project ]] && project="${BASH_REMATCH[1]}"
+            [[ "$content" =~ $re_name ]] && name="${BASH_REMATCH[1]}"
+            [ -n "$status" ] || continue
+            printf '%s\t%s\n' "$session" "$status" >> "$JOURNAL_DIR/state.tmp"
+            [ "${previous[$session]:-}" = "$status" ] && continue
+            seq=$((seq + 1))
+            events+=$(printf '{"seq":%s,"time":%s,"session_id":"%s","project":"%s","project_name":"%s","from":"%s","to":"%s"}' \
+                "$seq" "$now" "$session" "$project" "$name" "${previous[$session]:-}" "$status")$'\n'
+        done
+        mv -f "$JOURNAL_DIR/state.tmp" "$JOURNAL_DIR/state"
+
+        if [ -n "$events" ]; then
+            printf '%s' "$events" >> "$JOURNAL_DIR/journal.log"
+            echo "$seq" > "$JOURNAL_DIR/seq"
+            if [ "$(stat -c %s "$JOURNAL_DIR/journal.log" 2>/dev/null || echo 0)" -gt "$JOURNAL_MAX" ]; then
+                local i
+                for ((i = JOURNAL_KEEP; i > 1; i--)); do
+                    [ -f "$JOURNAL_DIR/journal.log.$((i - 1))" ] && mv -f "$JOURNAL_DIR/journal.log.$((i - 1))" "$JOURNAL_DIR/journal.log.$i"
+                done
+                mv -f "$JOURNAL_DIR/journal.log" "$JOURNAL_DIR/journal.log.1"
+            fi
+        fi
+    } 6> "$JOURNAL_DIR/lock"
+    notify_events "$events"
+}
+
+# 服务端通知：会话状态变化满足 NOTIFY_EVENTS 且没有有效的客户端租约（或 always=1）时，由记录该变化的
+# 实例经 webhook（POST JSON）、SMTP 中继（curl）或本地命令推送。配置由客户端安装时写入 NOTIFY_CONF
+# （每行 key=value，空文件表示关闭），修改后在下一次定期检查时重新读取。客户端连接期间经 lease 命令
+# 续租（LEASE_FILE 保存到期时间），笔记本合上、连接断开后租约到期即开始推送。配置了通知时守护进程
+# 在最后一个连接断开后不再因空闲退出
+NOTIFY_CONF="$STATUS_DIR/notify.conf"
+NOTIFY_CURL_CONF="$STATUS_DIR/.notify-curl.conf"
+LEASE_FILE="$STATUS_DIR/lease"
+NOTIFY_MTIME=""
+NOTIFY_ENABLED=0
+
+# 读取 NOTIFY_CONF（文件未变化时跳过）
+load_notify_conf() {
+    local mtime key value
+    mtime=$(stat -c %Y "$NOTIFY_CONF" 2>/dev/null)
+    [ "$mtime" = "$NOTIFY_MTIME" ] && return
+    NOTIFY_MTIME="$mtime"
+    NOTIFY_WEBHOOK=""
+    NOTIFY_SMTP_URL=""
+    NOTIFY_SMTP_FROM=""
+    NOTIFY_SMTP_TO=()
+    NOTIFY_SMTP_USER=""
+    NOTIFY_SMTP_PASSWORD=""
+    NOTIFY_SMTP_STARTTLS=""
+    NOTIFY_COMMAND=""
+    NOTIFY_EVENTS=" idle permission "
+    NOTIFY_ALWAYS=""
+    if [ -f "$NOTIFY_CONF" ]; then
+        while IFS='=' read -r key value; do
+            case "$key" in
+                webhook) NOTIFY_WEBHOOK="$value" ;;
+                smtp_url) NOTIFY_SMTP_URL="$value" ;;
+                smtp_from) NOTIFY_SMTP_FROM="$value" ;;
+                smtp_to) NOTIFY_SMTP_TO+=("$value") ;;
+                smtp_user) NOTIFY_SMTP_USER="$value" ;;
+                smtp_password) NOTIFY_SMTP_PASSWORD="$value" ;;
+                smtp_starttls) NOTIFY_SMTP_STARTTLS="$value" ;;
+                command) NOTIFY_COMMAND="$value" ;;
+                events) NOTIFY_EVENTS=" ${value//,/ } " ;;
+                always) NOTIFY_ALWAYS="$value" ;;
+            esac
+        done < "$NOTIFY_CONF"
+    fi
+
+    NOTIFY_ENABLED=0
+    [ -n "$NOTIFY_WEBHOOK$NOTIFY_SMTP_URL$NOTIFY_COMMAND" ] && NOTIFY_ENABLED=1
+    # SMTP 认证经 curl 配置文件传入，避免密码出现在进程参数中
+    rm -f "$NOTIFY_CURL_CONF"
+    if [ -n "$NOTIFY_SMTP_USER" ]; then
+        value="$NOTIFY_SMTP_USER:$NOTIFY_SMTP_PASSWORD"
+        value="${value//\\/\\\\}"
+        (umask 077 && printf 'user = "%s"\n' "${value//\"/\\\"}" > "$NOTIFY_CURL_CONF")
+    fi
+    if [ "$NOTIFY_ENABLED" = 1 ]; then
+        echo "[notify] Enabled (events:$NOTIFY_EVENTS, always=${NOTIFY_ALWAYS:-0})" >&2
+        [ -n "$NOTIFY_WEBHOOK$NOTIFY_SMTP_URL" ] && ! command -v curl &> /dev/null \
+            && echo "[notify] Warning: curl not found, webhook and SMTP disabled" >&2
+    fi
+}
+
+# 是否有客户端租约未到期
+lease_active() {
+    local until now
+    until=$(cat "$LEASE_FILE" 2>/dev/null)
+    printf -v now '%(%s)T' -1
+    [[ "$until" =~ ^[0-9]+$ ]] && [ "$until" -gt "$now" ]
+}
+
+# 延长客户端租约到 $1 秒后（多个客户端取最晚的到期时间）
+renew_lease() {
+    local until now
+    until=$(cat "$LEASE_FILE" 2>/dev/null)
+    printf -v now '%(%s)T' -1
+    [[ "$until" =~ ^[0-9]+$ ]] && [ "$until" -ge $((now + $1)) ] && return
+    echo $((now + $1)) > "$LEASE_FILE"
+}
+
+# 推送一个事件（参数为事件日志中的一行），在后台执行，失败只记录日志
+send_notification() {
+    local line="$1" session="" project="" name="" from="" to="" time="" kind title message payload host subject tool=""
+    local file fields='(.session_id, .from, .to, .time, .project, .project_name) | (. // "" | tostring) + "\u0000"'
+    # 事件行与状态文件用 jq 解析：项目路径、名称与工具摘要中可能含有引号或反斜杠
+    {
+        IFS= read -r -d '' session && IFS= read -r -d '' from && IFS= read -r -d '' to \
+            && IFS= read -r -d '' time && IFS= read -r -d '' project && IFS= read -r -d '' name
+    } < <(jq -j "$fields" <<< "$line" 2>/dev/null)
+    [[ "$session" =~ ^[A-Za-z0-9_.-]+$ ]] || return 0
+    [[ "$time" =~ ^[0-9]+$ ]] || printf -v time '%(%s)T' -1
+    file="$STATUS_DIR/$session.json"
+    host=$(hostname 2>/dev/null)
+
+    case "$to" in
+        idle)
+            kind=idle
+            title="$name: 已完成，等待输入"
+            ;;
+        permission)
+            kind=permission
+            title="$name: 等待授权"
+            [ -f "$file" ] && tool=$(jq -r '[.tool_name // empty, .tool_summary // empty] | join(" ")' "$file" 2>/dev/null)
+            ;;
+        stopped)
+            kind=stopped
+            title="$name: 会话已结束"
+            ;;
+    esac
+    message="$title"$'\n'"项目: $project"$'\n'"会话: $session"$'\n'"服务器: $host"$'\n'"时间: $(date -d "@$time" '+%F %T' 2>/dev/null)"
+    [ -n "$tool" ] && message="$message"$'\n'"工具: $tool"
+
+    if [ -n "$NOTIFY_WEBHOOK" ] && command -v curl &> /dev/null; then
+        payload=$(jq -cn --arg event "$kind" --arg title "$title" --arg host "$host" --argjson time "$time" \
+            --arg session_id "$session" --arg project "$project" --arg project_name "$name" \
+            --arg from "$from" --arg to "$to" --arg tool "$tool" \
+            '{event: $event, title: $title, host: $host, time: $time, session_id: $session_id, project: $project,
+              project_name: $project_name, from: $from, to: $to, tool: $tool}')
+        curl -fsS -m 10 -H 'Content-Type: application/json' --data-binary "$payload" "$NOTIFY_WEBHOOK" > /dev/null \
+            && echo "[notify] Sent $kind for $session via webhook" >&2 \
+            || echo "[notify] Webhook failed for $session" >&2
+    fi
+
+    if [ -n "$NOTIFY_SMTP_URL" ] && command -v curl &> /dev/null; then
+        local args=(-fsS -m 30 --url "$NOTIFY_SMTP_URL" --mail-from "$NOTIFY_SMTP_FROM") rcpt
+        for rcpt in "${NOTIFY_SMTP_TO[@]}"; do
+            args+=(--mail-rcpt "$rcpt")
+        done
+        [ -f "$NOTIFY_CURL_CONF" ] && args+=(-K "$NOTIFY_CURL_CONF")
+        [ "$NOTIFY_SMTP_STARTTLS" = 1 ] && args+=(--ssl-reqd)
+        subject="=?UTF-8?B?$(printf '[claude-status] %s' "$title" | base64 | tr -d '\n')?="
+        printf 'Date: %s\r\nFrom: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n' \
+            "$(date -R)" "$NOTIFY_SMTP_FROM" "$(IFS=,; echo "${NOTIFY_SMTP_TO[*]}")" "$subject" "${message//$'\n'/$'\r\n'}" \
+            | curl "${args[@]}" -T - \
+            && echo "[notify] Sent $kind for $session via SMTP" >&2 \
+            || echo "[notify] SMTP failed for $session" >&2
+    fi
+
+    if [ -n "$NOTIFY_COMMAND" ]; then
+        CLAUDE_STATUS_EVENT="$kind" CLAUDE_STATUS_TITLE="$title" CLAUDE_STATUS_MESSAGE="$message" \
+        CLAUDE_STATUS_SESSION="$session" CLAUDE_STATUS_PROJECT="$project" CLAUDE_STATUS_PROJECT_NAME="$name" \
+        CLAUDE_STATUS_FROM="$from" CLAUDE_STATUS_STATUS="$to" CLAUDE_STATUS_TOOL="$tool" \
+            timeout 60 bash -c "$NOTIFY_COMMAND" < /dev/null > /dev/null \
+            && echo "[notify] Sent $kind for $session via command" >&2 \
+            || echo "[notify] Command failed for $session" >&2
+    fi
+}
+
+# 按配置推送新记录的事件（每行一个）：idle 只在一轮结束（运行中/压缩中 → 等待输入）时推送
+notify_events() {
+    local line to from
+    local re_from='"from":"([^"]*)"' re_to='"to":"([^"]*)"'
+    [ "$NOTIFY_ENABLED" = 1 ] || return 0
+    [ "$NOTIFY_ALWAYS" = 1 ] || ! lease_active || return 0
+    while IFS= read -r line; do
+        [[ "$line" =~ $re_to ]] || continue
+        to="${BASH_REMATCH[1]}"
+        from=""
+        [[ "$line" =~ $re_from ]] && from="${BASH_REMATCH[1]}"
+        [[ "$NOTIFY_EVENTS" == *" $to "* ]] || continue
+        [ "$to" = idle ] && [ "$from" != working ] && [ "$from" != compacting ] && continue
+        send_notification "$line" < /dev/null &
+    done <<< "$1"
+}
+
+# 向当前连接发送其 POS 之后的事件（按订阅过滤），replay=$1；$2 为补发的起始序号（不改变 POS）
+send_events() {
+    local replay="$1" from="${2:-${POS[$CLIENT]:-0}}" to="" jid line data=""
+    local re_seq='^\{"seq":([0-9]+),'
+    [ "$replay" = true ] && to="${POS[$CLIENT]:-0}"
+    jid=$(journal_id)
+    while IFS= read -r line; do
+        [[ "$line" =~ $re_seq ]] || continue
+        [ "$replay" = false ] && POS[$CLIENT]="${BASH_REMATCH[1]}"
+        subscribed "$line" || continue
+        data+="{\"type\":\"event\",\"journal\":\"$jid\",\"replay\":$replay,\"event\":$line}"$'\n'
+    done < <(journal_since "$from" "$to")
+    [ -n "$data" ] && emit "${data%$'\n'}"
+    return 0
+}
+
+# 无 Hook 会话的后备检测：Hook 安装前启动的会话或无法修改 settings 的环境不会写状态文件，
+# 改从 Claude Code 的会话记录（projects/<项目>/<session_id>.jsonl）推断状态。
+# 只检查最近 TRANSCRIPT_MAX_AGE 分钟内修改过的记录，已有 Hook 状态文件的会话跳过。
+TRANSCRIPT_DIR="${CLAUDE_CONFIG_DIR:-$HOME/.claude}/projects"
+TRANSCRIPT_MAX_AGE=60
+TRANSCRIPT_DATA=""
+
+# 从会话记录最后的 user/assistant 条目推断状态，输出单行 JSON（source=transcript）：
+#   assistant 以 end_turn 结束或只有文本       -> idle
+#   assistant 含 tool_use（等待工具结果）       -> working，并记录最近的工具
+#   user（提示词或工具结果）                   -> working；用户中断或本地命令输出 -> idle
+JQ_TRANSCRIPT='
+def clip: gsub("[[:cntrl:]]+"; " ") | if length > 120 then .[0:117] + "..." else . end;
+[inputs | fromjson? // empty | select((.type == "user" or .type == "assistant") and (.isMeta | not))] as $entries |
+($entries | last) as $last |
+if $last == null then empty else
+    ([$entries[] | .cwd // empty] | last // "") as $cwd |
+    ([$entries[] | .gitBranch // empty] | last // "") as $branch |
+    ($last.message.content // "") as $content |
+    ([$content | arrays | .[] | select(.type? == "tool_use")] | last) as $tool |
+    (if $last.type == "assistant" then
+        (if $last.message.stop_reason == "end_turn" or $tool == null then "idle" else "working" end)
+     elif ($content | tostring | test("\\[Request interrupted by user|<local-command-stdout>")) then "idle"
+     else "working"
+     end) as $status |
+    {
+        project: $cwd,
+        project_name: ($cwd | split("/") | last // ""),
+        session_id: $sid,
+        status: $status,
+        updated_at: $mtime,
+        source: "transcript"
+    } + (if $branch != "" then {git_branch: $branch} else {} end)
+      + (if $status == "working" and $tool != null then
+        ($tool.input // {}) as $in |
+        {
+            last_tool: ($tool.name // "" | tostring | clip),
+            last_tool_summary: (if ($in | type) != "object" then ""
+                elif $in.command then $in.command | tostring | split("\n")[0]
+                else ($in.file_path // $in.notebook_path // $in.url // $in.pattern // $in.query // "")
+                     | tostring | ltrimstr($cwd + "/")
+                end | clip)
+        }
+    else {} end)
+end
+'
+
+# 重新扫描会话记录，结果保存在 TRANSCRIPT_DATA（每行 "session_id<TAB>JSON"），有变化时返回 0
+refresh_transcripts() {
+    local data="" file session mtime entry
+    if command -v jq &> /dev/null && [ -d "$TRANSCRIPT_DIR" ]; then
+        while IFS= read -r file; do
+            session="${file##*/}"
+            session="${session%.jsonl}"
+            [ -f "$STATUS_DIR/$session.json" ] && continue
+            [[ "$DISMISSED" == *" $session "* ]] && continue
+            mtime=$(stat -c %Y "$file" 2>/dev/null) || continue
+            entry=$(tail -n 50 "$file" 2>/dev/null | jq -cnR --arg sid "$session" --argjson mtime "$mtime" "$JQ_TRANSCRIPT" 2>/dev/null)
+            [ -n "$entry" ] && data="$data$session"$'\t'"$entry"$'\n'
+        done < <(find "$TRANSCRIPT_DIR" -mindepth 2 -maxdepth 2 -name '*.jsonl' ! -name 'agent-*' \
+            -mmin -"$TRANSCRIPT_MAX_AGE" 2>/dev/null | sort)
+    fi
+
+    [ "$data" = "$TRANSCRIPT_DATA" ] && return 1
+    TRANSCRIPT_DATA="$data"
+    return 0
+}
+
+# Token 用量：按模型累计会话记录中 assistant 消息的 usage。
+# 增量读取：USAGE_DIR/<session_id>.json 保存已读取的字节偏移、最后一条消息 ID、最近 24 小时按整点
+# 分桶的 Token 数（hours，用于计算用量窗口）与按模型的累计值（单行 JSON，models 为最后一个字段），
+# 每次只解析新增内容。同一消息的多个内容块重复携带 usage，按消息 ID 去重。
+USAGE_DIR="$STATUS_DIR/usage"
+USAGE_INITIAL='{"offset":0,"last_id":"","hours":{},"models":{}}'
+
+JQ_USAGE='
+[inputs] as $lines |
+# 最后一行可能尚未写完（解析失败），留到下次读取
+(if ($lines | length) > 0 and ((($lines | last | fromjson? | true) // false) | not)
+ then $lines[:-1] else $lines end) as $complete |
+reduce ($complete[] | fromjson? // empty
+        | select(.type == "assistant" and .message.usage != null and .message.model != "<synthetic>")) as $e (
+    $state | .offset += ($complete | map(utf8bytelength + 1) | add // 0);
+    if ($e.message.id // "") != "" and $e.message.id == .last_id then .
+    else
+        .last_id = ($e.message.id // "") |
+        ($e.message.model // "unknown") as $m |
+        $e.message.usage as $u |
+        (($e.timestamp // "") | sub("\\.[0-9]+"; "") | fromdateiso8601? // null) as $ts |
+        (if $ts == null then . else
+            .hours[($ts / 3600 | floor) * 3600 | tostring] +=
+                ([$u.input_tokens, $u.output_tokens, $u.cache_creation_input_tokens, $u.cache_read_input_tokens]
+                 | map(. // 0) | add)
+        end) |
+        .models[$m].input_tokens += ($u.input_tokens // 0) |
+        .models[$m].output_tokens += ($u.output_tokens // 0) |
+        .models[$m].cache_creation_input_tokens += ($u.cache_creation_input_tokens // 0) |
+        .models[$m].cache_read_input_tokens += ($u.cache_read_input_tokens // 0)
+    end
+) |
+.hours |= with_entries(select((.key | tonumber) >= $now - 86400)) |
+{offset, last_id, hours, models}
+'
+
+# 用量窗口（订阅套餐的 5 小时滚动窗口）：窗口从第一条用量记录所在的整点开始，持续 BLOCK_SECONDS；
+# 窗口结束后的第一条记录开启新窗口。汇总所有会话的 hours 分桶，输出当前窗口（已结束则不含 block）
+BLOCK_SECONDS=18000
+USAGE_BLOCK=""
+
+JQ_BLOCK='
+[.[] | .hours // {} | to_entries[] | {h: (.key | tonumber), t: .value}]
+| group_by(.h) | map({h: .[0].h, t: (map(.t) | add)}) | sort_by(.h)
+| reduce .[] as $x (null;
+    if . == null or $x.h >= .start + $window then {start: $x.h, tokens: $x.t} else .tokens += $x.t end)
+| {type: "usage_block"}
+  + (if . != null and $now < .start + $window
+     then {block: {start: .start, "end": (.start + $window), tokens: .tokens}}
+     else {} end)
+'
+
+# 输出会话记录文件路径，找不到时返回 1
+transcript_path() {
+    local file
+    for file in "$TRANSCRIPT_DIR"/*/"$1.jsonl"; do
+        [ -f "$file" ] && echo "$file" && return 0
+    done
+    return 1
+}
+
+# 更新状态文件与推断会话的 Token 用量缓存，有变化时返回 0
+refresh_usage() {
+    local changed=1 session file size state offset new
+    command -v jq &> /dev/null && [ -d "$TRANSCRIPT_DIR" ] || return 1
+    mkdir -p "$USAGE_DIR"
+
+    while IFS= read -r session; do
+        [ -n "$session" ] || continue
+        file=$(transcript_path "$session") || continue
+        size=$(stat -c %s "$file" 2>/dev/null) || continue
+
+        state="$USAGE_INITIAL"
+        [ -f "$USAGE_DIR/$session.json" ] && state=$(cat "$USAGE_DIR/$session.json")
+        offset=$(echo "$state" | grep -oE '^\{"offset":[0-9]+' | grep -oE '[0-9]+$')
+        # 会话记录被截断或重写、或旧版本缓存缺少 hours 时从头统计
+        if [ -z "$offset" ] || [ "$size" -lt "$offset" ] || [[ "$state" != *'"hours":'* ]]; then
+            state="$USAGE_INITIAL"
+            offset=0
+        fi
+        [ "$size" -gt "$offset" ] || continue
+
+        new=$(tail -c +$((offset + 1)) "$file" | jq -cnR --argjson state "$state" --argjson now "$(date +%s)" \
+            "$JQ_USAGE" 2>/dev/null) || continue
+        [ -n "$new" ] && [ "$new" != "$state" ] || continue
+        echo "$new" > "$USAGE_DIR/$session.json"
+        changed=0
+    done < <({
+        for file in "$STATUS_DIR"/*.json; do
+            [ -f "$file" ] && session="${file##*/}" && echo "${session%.json}"
+        done
+        # 用量窗口需要最近两个窗口内所有会话（含子代理）的记录
+        find "$TRANSCRIPT_DIR" -mindepth 2 -maxdepth 2 -name '*.jsonl' -mmin -$((BLOCK_SECONDS * 2 / 60)) \
+            2>/dev/null | sed 's|.*/||; s|\.jsonl$||'
+    } | sort -u)
+    return $changed
+}
+
+# 重新计算当前用量窗口，结果保存在 USAGE_BLOCK，有变化时返回 0
+refresh_block() {
+    local block
+    command -v jq &> /dev/null || return 1
+    block=$(cat "$USAGE_DIR"/*.json 2>/dev/null | jq -cs --argjson now "$(date +%s)" \
+        --argjson window "$BLOCK_SECONDS" "$JQ_BLOCK" 2>/dev/null) || return 1
+    [ -n "$block" ] && [ "$block" != "$USAGE_BLOCK" ] || return 1
+    USAGE_BLOCK="$block"
+    return 0
+}
+
+# 将 Token 用量缓存中的 models 作为 usage 字段追加到单行状态 JSON
+with_usage() {
+    local content="$1" state usage
+    if [ -f "$USAGE_DIR/$2.json" ]; then
+        state=$(cat "$USAGE_DIR/$2.json")
+        usage="${state#*\"models\":}"
+        usage="${usage%\}}"
+        if [ -n "$usage" ] && [ "$usage" != "{}" ] && [ "$usage" != "$state" ]; then
+            content="${content%\}},\"usage\":$usage}"
+        fi
+    fi
+    printf '%s' "$content"
+}
+
+# 会话是否在 subscribe 的过滤范围内（参数为单行状态 JSON），未设置过滤时总是返回 0
+subscribed() {
+    local project="" name="" filter
+    local re_project='"project"[[:space:]]*:[[:space:]]*"([^"]*)"'
+    local re_name='"project_name"[[:space:]]*:[[:space:]]*"([^"]*)"'
+    [ ${#SUBSCRIBE[@]} -eq 0 ] && return 0
+    [[ "$1" =~ $re_project ]] && project="${BASH_REMATCH[1]}"
+    [[ "$1" =~ $re_name ]] && name="${BASH_REMATCH[1]}"
+    for filter in "${SUBSCRIBE[@]}"; do
+        filter="${filter%/}"
+        [ "$project" = "$filter" ] || [[ "$project" == "$filter"/* ]] || [ "$name" = "$filter" ] && return 0
+    done
+    return 1
+}
+
 # 输出所有状态的 JSON 函数
 output_status() {
     local data="[]"
     local first=true
     local file_count=0
+    local session
 
     # 调试：列出状态目录中的文件
     echo "[output_status] Scanning $STATUS_DIR" >&2
@@ -79,8 +792,11 @@ output_status() {
         file_count=$((file_count + 1))
         echo "[output_status] Found: $file" >&2
 
-        # 读取并压缩成单行（移除换行符）
+        # 读取并压缩成单行（移除换行符），附加 Token 用量
         content=$(tr -d '\n' < "$file" 2>/dev/null || echo "{}")
+        subscribed "$content" || continue
+        session="${file##*/}"
+        content=$(with_usage "$content" "${session%.json}")
 
         if [ "$first" = true ]; then
             data="[$content"
@@ -90,18 +806,102 @@ output_status() {
         fi
     done
 
+    # 追加由会话记录推断的会话（期间已出现 Hook 状态文件的跳过）
+    local entry
+    while IFS=$'\t' read -r session entry; do
+        [ -n "$session" ] || continue
+        [ -f "$STATUS_DIR/$session.json" ] && continue
+        subscribed "$entry" || continue
+        file_count=$((file_count + 1))
+        entry=$(with_usage "$entry" "$session")
+        if [ "$first" = true ]; then
+            data="[$entry"
+            first=false
+        else
+            data="$data,$entry"
+        fi
+    done <<< "$TRANSCRIPT_DATA"
+
     if [ "$first" = false ]; then
         data="$data]"
     fi
 
     echo "[output_status] Sending $file_count files" >&2
-    echo "{\"type\":\"status\",\"data\":$data}"
+    # now 为服务端当前时间，客户端据此估计时钟偏差
+    local now
+    printf -v now '%(%s)T' -1
+    emit "{\"type\":\"status\",\"now\":$now,\"data\":$data}"
+}
+
+# 检测状态文件记录的 Claude Code 进程是否存活（依据 pid 与 pid_start，需要 /proc）
+# 返回 0 表示存活，1 表示进程已退出或 PID 已被复用，2 表示无法判断（未记录 pid 或无 /proc）
+session_liveness() {
+    local file="$1" pid start stat rest
+    pid=$(grep -oE '^  "pid"[[:space:]]*:[[:space:]]*[0-9]+' "$file" | grep -oE '[0-9]+$')
+    start=$(grep -oE '^  "pid_start"[[:space:]]*:[[:space:]]*[0-9]+' "$file" | grep -oE '[0-9]+$')
+    [ -n "$pid" ] && [ -d /proc/self ] || return 2
+
+    { read -r stat < "/proc/$pid/stat"; } 2>/dev/null || return 1
+    # 去掉 "pid (comm) " 后，下标 19 为 starttime
+    rest=(${stat##*) })
+    if [ -n "$start" ] && [ "${rest[19]}" != "$start" ]; then
+        return 1
+    fi
+    return 0
+}
+
+# 将进程已退出的会话标记为 stopped（Claude Code 被杀或终端关闭时不会触发 SessionEnd）
+# 有会话被标记时返回 0
+mark_dead_sessions() {
+    local changed=1
+    for file in "$STATUS_DIR"/*.json; do
+        [ -f "$file" ] || continue
+        grep -qE '^  "status"[[:space:]]*:[[:space:]]*"stopped"' "$file" && continue
+        session_liveness "$file"
+        [ $? -eq 1 ] || continue
+
+        echo "[liveness] Process exited, marking stopped: $file" >&2
+        sed -E 's/^  "status"[[:space:]]*:[[:space:]]*"[^"]*"/  "status": "stopped"/' "$file" > "$file.tmp.$$" \
+            && mv -f "$file.tmp.$$" "$file"
+        rm -f "$file.tmp.$$"
+        changed=0
+    done
+    return $changed
+}
+
+# 会话归档：RETENTION_ARCHIVE=1 时清理的会话以单行 JSON（附 archived_at 与 Token 用量）追加到
+# HISTORY_DIR/sessions.jsonl，超过 HISTORY_MAX 字节时轮转为 sessions.jsonl.1..HISTORY_KEEP
+HISTORY_DIR="$STATUS_DIR/history"
+HISTORY_MAX=1048576
+HISTORY_KEEP=3
+
+# 归档单个状态文件，失败时返回非 0（调用方保留该文件）
+archive_session() {
+    local file="$1" session now content i
+    session="${file##*/}"
+    session="${session%.json}"
+    printf -v now '%(%s)T' -1
+    content=$(tr -d '\n' < "$file" 2>/dev/null) || return 1
+    content=$(with_usage "$content" "$session" | jq -c --argjson now "$now" '. + {archived_at: $now}' 2>/dev/null) || return 1
+    [ -n "$content" ] || return 1
+    mkdir -p "$HISTORY_DIR" || return 1
+    {
+        flock 6
+        printf '%s\n' "$content" >> "$HISTORY_DIR/sessions.jsonl" || return 1
+        if [ "$(stat -c %s "$HISTORY_DIR/sessions.jsonl" 2>/dev/null || echo 0)" -gt "$HISTORY_MAX" ]; then
+            for ((i = HISTORY_KEEP; i > 1; i--)); do
+                [ -f "$HISTORY_DIR/sessions.jsonl.$((i - 1))" ] && mv -f "$HISTORY_DIR/sessions.jsonl.$((i - 1))" "$HISTORY_DIR/sessions.jsonl.$i"
+            done
+            mv -f "$HISTORY_DIR/sessions.jsonl" "$HISTORY_DIR/sessions.jsonl.1"
+        fi
+    } 6> "$HISTORY_DIR/lock"
 }
 
-# 清理过期状态文件（超过 1 小时未更新的视为过期）
+# 按保留策略清理过期状态文件，有会话被清理时返回 0
+# 未结束的会话记录了 pid 且进程仍存活时不按时长清理，由 mark_dead_sessions 负责标记为 stopped
 cleanup_stale() {
     local now=$(date +%s)
-    local max_age=3600  # 1 小时
+    local removed=1 max_age stopped
 
     for file in "$STATUS_DIR"/*.json; do
         [ -f "$file" ] || continue
@@ -116,27 +916,388 @@ cleanup_stale() {
         fi
 
         age=$((now - updated_at))
+        stopped=0
+        max_age="$RETENTION_SECONDS"
+        if grep -qE '^  "status"[[:space:]]*:[[:space:]]*"stopped"' "$file"; then
+            stopped=1
+            max_age="$STOPPED_RETENTION"
+        fi
 
         if [ "$age" -gt "$max_age" ]; then
-            echo "[cleanup_stale] Removing stale file: $file (age=${age}s)" >&2
+            if [ "$stopped" = 0 ] && session_liveness "$file"; then
+                continue
+            fi
+            if [ "$RETENTION_ARCHIVE" = 1 ]; then
+                if ! archive_session "$file"; then
+                    echo "[cleanup_stale] Warning: Failed to archive $file, keeping it" >&2
+                    continue
+                fi
+                echo "[cleanup_stale] Archived stale file: $file (age=${age}s)" >&2
+            else
+                echo "[cleanup_stale] Removing stale file: $file (age=${age}s)" >&2
+            fi
             rm -f "$file"
+            removed=0
+        fi
+    done
+
+    # 清理一天未更新的 Token 用量缓存（会话仍在时下次从头统计）
+    [ -d "$USAGE_DIR" ] && find "$USAGE_DIR" -name '*.json' -mmin +1440 -delete 2>/dev/null
+    return $removed
+}
+
+# 回复控制命令：respond <id> <command> <ok:true|false> <message>（带服务端当前时间，ping 即心跳）
+respond() {
+    local message="${4//\\/\\\\}" now
+    message="${message//\"/\\\"}"
+    printf -v now '%(%s)T' -1
+    emit "$(printf '{"type":"response","id":%s,"command":"%s","ok":%s,"message":"%s","now":%s}' "$1" "$2" "$3" "$message" "$now")"
+}
+
+# 执行一条控制命令：handle_command <id> <command> [args...]
+handle_command() {
+    local id="$1" command="$2"
+    shift 2
+    case "$command" in
+        ping)
+            respond "$id" "$command" true "pong"
+            ;;
+        resync)
+            mark_dead_sessions
+            refresh_transcripts
+            refresh_usage
+            USAGE_BLOCK=""
+            refresh_block && emit "$USAGE_BLOCK"
+            output_status
+            respond "$id" "$command" true ""
+            ;;
+        subscribe)
+            SUBSCRIBE=("$@")
+            : > "$CLIENTS_DIR/$CLIENT/subscribe"
+            [ $# -gt 0 ] && printf '%s\n' "$@" > "$CLIENTS_DIR/$CLIENT/subscribe"
+            output_status
+            respond "$id" "$command" true "${SUBSCRIBE[*]}"
+            ;;
+        set-retention)
+            local stopped="${2:-${1:-}}" archive="${3:-0}"
+            if ! [[ "${1:-}" =~ ^[0-9]+$ ]] || [ "$1" -lt 60 ] || ! [[ "$stopped" =~ ^[0-9]+$ ]] || [ "$stopped" -lt 60 ]; then
+                respond "$id" "$command" false "保留时长须为不小于 60 的整数（秒）"
+            elif ! [[ "$archive" =~ ^[01]$ ]]; then
+                respond "$id" "$command" false "归档选项须为 0 或 1: $archive"
+            else
+                RETENTION_SECONDS="$1"
+                STOPPED_RETENTION="$stopped"
+                RETENTION_ARCHIVE="$archive"
+                printf 'idle=%s\nstopped=%s\narchive=%s\n' "$1" "$stopped" "$archive" > "$RETENTION_CONF.tmp.$$" \
+                    && mv -f "$RETENTION_CONF.tmp.$$" "$RETENTION_CONF"
+                cleanup_stale
+                STATE_CHANGED=1
+                respond "$id" "$command" true "$RETENTION_SECONDS $STOPPED_RETENTION $RETENTION_ARCHIVE"
+            fi
+            ;;
+        set-debounce)
+            if [[ "${1:-}" =~ ^[0-9]+$ ]] && [ "$1" -le 5000 ]; then
+                DEBOUNCE_MS="$1"
+                respond "$id" "$command" true "$DEBOUNCE_MS"
+            else
+                respond "$id" "$command" false "合并窗口须为 0 到 5000 之间的整数（毫秒）"
+            fi
+            ;;
+        delete-session)
+            if ! [[ "${1:-}" =~ ^[A-Za-z0-9_.-]+$ ]]; then
+                respond "$id" "$command" false "无效的会话 ID: ${1:-}"
+            elif [ -f "$STATUS_DIR/$1.json" ] || [[ "$TRANSCRIPT_DATA" == *"$1"$'\t'* ]]; then
+                rm -f "$STATUS_DIR/$1.json" "$USAGE_DIR/$1.json"
+                DISMISSED="$DISMISSED$1 "
+                refresh_transcripts
+                STATE_CHANGED=1
+                respond "$id" "$command" true "$1"
+            else
+                respond "$id" "$command" false "会话不存在: $1"
+            fi
+            ;;
+        lease)
+            if [[ "${1:-}" =~ ^[0-9]+$ ]] && [ "$1" -ge 10 ] && [ "$1" -le 3600 ]; then
+                renew_lease "$1"
+                respond "$id" "$command" true "$(cat "$LEASE_FILE" 2>/dev/null)"
+            else
+                respond "$id" "$command" false "租约时长须为 10 到 3600 之间的整数（秒）"
+            fi
+            ;;
+        replay)
+            local jid
+            jid=$(journal_id)
+            if [ $# -ge 2 ] && ! [[ "$2" =~ ^[0-9]+$ ]]; then
+                respond "$id" "$command" false "无效的事件序号: $2"
+            else
+                # 日志 ID 不同（日志已被删除重建）时从头补发；序号超出当前位置时不补发
+                if [ $# -ge 2 ]; then
+                    if [ "$1" = "$jid" ]; then
+                        send_events true "$2"
+                    else
+                        send_events true 0
+                    fi
+                fi
+                respond "$id" "$command" true "$jid ${POS[$CLIENT]:-0}"
+            fi
+            ;;
+        reply)
+            local text="" out
+            if [ $# -ge 4 ] && { ! [[ "$4" =~ ^[A-Za-z0-9+/]+=*$ ]] || ! text=$(printf '%s' "$4" | base64 -d 2>/dev/null); }; then
+                respond "$id" "$command" false "无效的回复内容"
+            elif out=$(bash "$STATUS_DIR/reply.sh" "${1:-}" "${2:-}" "${3:-}" "$text" 2>&1); then
+                respond "$id" "$command" true "$out"
+            else
+                out="${out//$'\n'/ }"
+                out="${out#Error: }"
+                respond "$id" "$command" false "${out:-回复失败}"
+            fi
+            ;;
+        *)
+            respond "$id" "$command" false "未知命令: $command"
+            ;;
+    esac
+}
+
+# 处理各连接 queue 中尚未处理的控制命令（格式错误、没有数字 ID 的行忽略）
+process_commands() {
+    local line id command args
+    for CLIENT in "${!DONE[@]}"; do
+        [ -f "$CLIENTS_DIR/$CLIENT/queue" ] || continue
+        load_subscription
+        while IFS= read -r line; do
+            DONE[$CLIENT]=$((DONE[$CLIENT] + 1))
+            # 按空白拆分为数组，参数不做通配符展开
+            read -ra args <<< "$line"
+            id="${args[0]:-}"
+            command="${args[1]:-}"
+            [[ "$id" =~ ^[0-9]+$ ]] && [[ "$command" =~ ^[a-z-]+$ ]] || continue
+            handle_command "$id" "$command" "${args[@]:2}"
+        done < <(tail -n +$((DONE[$CLIENT] + 1)) "$CLIENTS_DIR/$CLIENT/queue")
+    done
+}
+
+# 是否有连接的 queue 中存在尚未处理的命令（在 inotifywait 未运行期间追加的命令不会再触发事件）
+commands_pending() {
+    local id lines
+    for id in "${!DONE[@]}"; do
+        [ -f "$CLIENTS_DIR/$id/queue" ] || continue
+        lines=$(wc -l < "$CLIENTS_DIR/$id/queue" 2>/dev/null) || continue
+        [ "${lines:-0}" -gt "${DONE[$id]}" ] && return 0
+    done
+    return 1
+}
+
+# 启动 socket 监听：socat 为每个连接启动一个 --serve-client 处理进程（不继承守护进程持有的锁）
+start_listener() {
+    socat UNIX-LISTEN:"$DAEMON_SOCKET",fork,unlink-early,mode=600 EXEC:"bash $SCRIPT_PATH --serve-client" \
+        < /dev/null 7>&- 8>&- &
+    LISTENER=$!
+}
+
+# 守护进程退出：停止监听与各连接的处理进程；被新版本接管（USR1）时保留 Hook 与状态文件
+daemon_cleanup() {
+    local id
+    [ -n "$LISTENER" ] && kill "$LISTENER" 2>/dev/null
+    for id in "${!DONE[@]}"; do
+        kill "$id" 2>/dev/null
+    done
+    rm -rf "$CLIENTS_DIR" "$DAEMON_SOCKET" "$DAEMON_DIR/pid" "$DAEMON_DIR/version"
+    if [ "$REPLACED" = "1" ]; then
+        echo "[daemon] Replaced by a new version, keeping hooks and status files" >&2
+        return
+    fi
+    cleanup
+}
+
+# 同步连接列表：移除处理进程已退出的连接，向新连接发送用量窗口与完整状态（版本由处理进程发送）
+sync_clients() {
+    local dir id
+    for id in "${!DONE[@]}"; do
+        if [ ! -d "$CLIENTS_DIR/$id" ] || ! kill -0 "$id" 2>/dev/null; then
+            echo "[daemon] Client $id disconnected" >&2
+            rm -rf "${CLIENTS_DIR:?}/$id"
+            unset "DONE[$id]" "POS[$id]"
         fi
     done
+    for dir in "$CLIENTS_DIR"/*/; do
+        [ -d "$dir" ] || continue
+        id=$(basename "$dir")
+        [ -n "${DONE[$id]+x}" ] && continue
+        if ! kill -0 "$id" 2>/dev/null; then
+            rm -rf "$dir"
+            continue
+        fi
+        echo "[daemon] Client $id connected" >&2
+        DONE[$id]=0
+        POS[$id]=$(journal_seq)
+        CLIENT=$id
+        load_subscription
+        [ -n "$USAGE_BLOCK" ] && emit "$USAGE_BLOCK"
+        output_status
+    done
 }
 
-# 禁用 stdout 缓冲
-exec 1> >(cat)
+# 守护进程的定期维护：断开积压过多（连接停滞）的连接，限制日志大小，空闲超时后退出。
+# 退出前持有启动锁并先删除 socket，再确认期间没有新连接，避免与正在启动的桥接进程竞争
+daemon_housekeeping() {
+    local id size
+    for id in "${!DONE[@]}"; do
+        size=$(stat -c %s "$CLIENTS_DIR/$id/out" 2>/dev/null) || continue
+        if [ "$size" -gt "$CLIENT_OUT_MAX" ]; then
+            echo "[daemon] Client $id stalled ($size bytes pending), disconnecting" >&2
+            kill "$id" 2>/dev/null
+            rm -rf "${CLIENTS_DIR:?}/$id"
+            unset "DONE[$id]" "POS[$id]"
+        fi
+    done
 
-# 首先输出版本信息
-echo "{\"type\":\"version\",\"version\":\"$SCRIPT_VERSION\"}"
+    size=$(stat -c %s "$DAEMON_DIR/daemon.log" 2>/dev/null)
+    [ "${size:-0}" -gt "$DAEMON_LOG_MAX" ] && : > "$DAEMON_DIR/daemon.log"
 
-# 启动时清理并输出初始状态
+    # 有连接或配置了服务端通知（需要继续监听）时不因空闲退出
+    if [ ${#DONE[@]} -gt 0 ] || [ "$NOTIFY_ENABLED" = 1 ]; then
+        IDLE_SINCE=""
+        return
+    fi
+    IDLE_SINCE="${IDLE_SINCE:-$now}"
+    [ $((now - IDLE_SINCE)) -ge "$DAEMON_IDLE_TIMEOUT" ] || return
+
+    exec 7> "$DAEMON_DIR/start.lock"
+    flock 7
+    rm -f "$DAEMON_SOCKET"
+    sync_clients
+    if [ ${#DONE[@]} -eq 0 ]; then
+        echo "[daemon] No clients for ${DAEMON_IDLE_TIMEOUT}s, exiting" >&2
+        exit 0
+    fi
+    # 删除 socket 前恰好有连接进入：继续服务并恢复监听
+    kill "$LISTENER" 2>/dev/null
+    start_listener
+    IDLE_SINCE=""
+    exec 7>&-
+}
+
+# 启动守护进程：单实例（daemon.lock），记录 PID 与版本后立即开始监听，初始扫描期间进入的连接
+# 已能收到版本消息
+start_daemon() {
+    mkdir -p "$DAEMON_DIR"
+    exec 8> "$DAEMON_DIR/daemon.lock"
+    if ! flock -n 8; then
+        echo "[daemon] Another daemon is running" >&2
+        exit 0
+    fi
+    REPLACED=0
+    LISTENER=""
+    trap daemon_cleanup EXIT
+    trap 'exit 0' HUP TERM INT
+    trap 'REPLACED=1; exit 0' USR1
+
+    rm -rf "$CLIENTS_DIR"
+    mkdir -p "$CLIENTS_DIR"
+    echo "$SCRIPT_VERSION" > "$DAEMON_DIR/version"
+    echo $$ > "$DAEMON_DIR/pid"
+    start_listener
+    echo "[daemon] Started (pid $$, version $SCRIPT_VERSION)" >&2
+}
+
+if [ "$MODE" = "daemon" ]; then
+    start_daemon
+else
+    # 禁用 stdout 缓冲
+    exec 1> >(cat)
+
+    # 首先输出版本信息
+    emit "{\"type\":\"version\",\"version\":\"$SCRIPT_VERSION\"}"
+    POS[$$]=$(journal_seq)
+fi
+
+# 启动时清理并输出初始状态（守护进程在连接进入时发送）
+[ "$MODE" = "once" ] || load_notify_conf
+load_retention_conf
 cleanup_stale
-output_status
+mark_dead_sessions
+refresh_transcripts
+refresh_usage
+refresh_block && publish_block
+publish_status
+
+if [ "$MODE" = "once" ]; then
+    exit 0
+fi
+
+# 独立模式的控制通道：清理已退出实例残留的队列，后台逐行读取 stdin
+# （后台命令默认从 /dev/null 读取，须显式重定向）
+if [ "$MODE" = "standalone" ]; then
+    for dir in "$CLIENTS_DIR"/*/; do
+        [ -d "$dir" ] && ! kill -0 "$(basename "$dir")" 2>/dev/null && rm -rf "$dir"
+    done
+    mkdir -p "$CLIENTS_DIR/$$"
+    : > "$CLIENTS_DIR/$$/queue"
+    exec 4<&0
+    while IFS= read -r line; do
+        printf '%s\n' "$line" >> "$CLIENTS_DIR/$$/queue"
+    done <&4 &
+    CONTROL_READER=$!
+    exec 4<&-
+fi
 
-# 使用 inotifywait 监听文件变化
-# 使用 --monitor 持续监听，每次变化输出状态
+# 使用 inotifywait 监听状态目录与各连接的 queue（守护进程还监听连接目录的增减），状态变化时输出。
+# 每 LIVENESS_INTERVAL 秒检测一次进程存活并重新扫描会话记录（按间隔而非超时计算，
+# Hook 持续写入时也不会被饿死），有变化才输出
+LIVENESS_INTERVAL=10
+printf -v last_check '%(%s)T' -1
+last_cleanup=$last_check
 while true; do
-    inotifywait -qq -e modify -e create -e delete "$STATUS_DIR" 2>/dev/null
-    output_status
+    [ "$MODE" = "daemon" ] && sync_clients
+    watch=("$STATUS_DIR")
+    [ "$MODE" = "daemon" ] && watch+=("$CLIENTS_DIR")
+    for CLIENT in "${!DONE[@]}"; do
+        watch+=("$CLIENTS_DIR/$CLIENT/queue")
+    done
+
+    # 上次输出开始后 inotifywait 未在运行，期间写入的状态文件（ctime 晚于该时间，重命名也会更新 ctime；
+    # 不计未来时间，避免时钟回拨后反复输出）直接按变化处理
+    if [ -n "$SNAPSHOT_AT" ] && [ -n "$(find "$STATUS_DIR" -maxdepth 1 -name '*.json' -newerct "@$SNAPSHOT_AT" \
+        ! -newerct "@$(date +%s.%N)" -print -quit 2>/dev/null)" ]; then
+        changed=0
+    elif commands_pending; then
+        # 处理期间到达的控制命令直接处理，不等待下一次事件或超时
+        changed=1
+    else
+        # 退出码 2 表示超时无变化；只有状态文件（*.json）的变化算状态变化，控制命令、连接变化与
+        # 租约等其他文件不算。Hook 先写临时文件再重命名，忽略临时文件本身的事件，以重命名（moved_to）为准
+        event=$(inotifywait -q -t "$LIVENESS_INTERVAL" -e modify -e create -e delete -e moved_to \
+            --exclude '\.tmp\.[0-9]+$' --format '%w%f' "${watch[@]}" 2>/dev/null)
+        [ $? -eq 0 ] && [[ "$event" == "$STATUS_DIR/"*.json ]] && changed=0 || changed=1
+    fi
+    if [ "$changed" = 0 ]; then
+        # 合并窗口：PostToolUse 等 Hook 频繁写入时，窗口内的变化合并为一次完整状态输出
+        [ "$DEBOUNCE_MS" -gt 0 ] && sleep "$(printf '%d.%03d' $((DEBOUNCE_MS / 1000)) $((DEBOUNCE_MS % 1000)))"
+    fi
+    [ "$MODE" = "daemon" ] && sync_clients
+    process_commands
+    [ "$STATE_CHANGED" = 1 ] && changed=0
+    STATE_CHANGED=0
+
+    printf -v now '%(%s)T' -1
+    if [ $((now - last_check)) -ge "$LIVENESS_INTERVAL" ]; then
+        last_check=$now
+        mark_dead_sessions && changed=0
+        refresh_transcripts && changed=0
+        refresh_usage && changed=0
+        refresh_block && publish_block
+        load_notify_conf
+        [ "$MODE" = "daemon" ] && daemon_housekeeping
+    fi
+    # 按保留策略定期清理（策略可能已被其他实例修改），同时记录期间的完整状态输出次数
+    if [ $((now - last_cleanup)) -ge "$CLEANUP_INTERVAL" ]; then
+        [ "$SNAPSHOTS" -gt 0 ] && echo "[coalesce] $((now - last_cleanup))s: $SNAPSHOTS snapshots (debounce ${DEBOUNCE_MS}ms)" >&2
+        SNAPSHOTS=0
+        last_cleanup=$now
+        load_retention_conf
+        cleanup_stale && changed=0
+    fi
+
+    [ "$changed" = 0 ] && publish_status
 done
@@ -0,0 +1,185 @@
+#!/bin/bash
+# Claude Code 插件模式安装脚本
+# 版本: __VERSION__
+# 将 status hooks 打包为独立插件（附带本地 marketplace），通过 claude plugin 命令启用/停用，
+# 不直接修改用户的 settings.json
+#
+# 用法:
+#   plugin.sh install [--scope <user|project|local|config_dir>] [目录...]
+#       project/local：目录为仓库路径，在仓库内以对应范围安装
+#       config_dir：目录为 CLAUDE_CONFIG_DIR，在该配置下以 user 范围安装
+#   plugin.sh uninstall     按 install.conf 中的记录停用并移除插件
+#   plugin.sh status        输出 claude plugin list 中的本插件信息
+
+set -u
+
+STATUS_DIR="$HOME/.claude-status"
+INSTALL_CONF="$STATUS_DIR/install.conf"
+HOOK_CMD="$STATUS_DIR/hooks/status-hook.sh"
+HOOKS_TEMPLATE="$STATUS_DIR/hooks/hooks.json"
+MARKETPLACE_DIR="$STATUS_DIR/plugin"
+MARKETPLACE_NAME="claude-status"
+PLUGIN_NAME="claude-status"
+PLUGIN_ID="$PLUGIN_NAME@$MARKETPLACE_NAME"
+
+die() {
+    echo "Error: $*" >&2
+    exit 1
+}
+
+# find_claude 查找 claude CLI（非交互式 shell 的 PATH 中常常不包含它）
+find_claude() {
+    local candidate
+    for candidate in "$(command -v claude 2>/dev/null)" \
+        "$HOME/.claude/local/claude" \
+        "$HOME/.local/bin/claude" \
+        "$HOME/.npm-global/bin/claude"; do
+        if [ -n "$candidate" ] && [ -x "$candidate" ]; then
+            echo "$candidate"
+            return 0
+        fi
+    done
+    return 1
+}
+
+CLAUDE_BIN="$(find_claude)" || die "claude CLI not found, plugin mode requires Claude Code with plugin support"
+
+# write_plugin 生成本地 marketplace 与插件目录
+write_plugin() {
+    local plugin_dir="$MARKETPLACE_DIR/$PLUGIN_NAME"
+    mkdir -p "$MARKETPLACE_DIR/.claude-plugin" "$plugin_dir/.claude-plugin" "$plugin_dir/hooks"
+
+    jq -n --arg name "$MARKETPLACE_NAME" --arg plugin "$PLUGIN_NAME" '{
+        name: $name,
+        owner: {name: "claude-status"},
+        plugins: [{name: $plugin, source: ("./" + $plugin), description: "Claude Code Status Monitor hooks"}]
+    }' > "$MARKETPLACE_DIR/.claude-plugin/marketplace.json"
+
+    jq -n --arg name "$PLUGIN_NAME" --arg version "__VERSION__" '{
+        name: $name,
+        version: $version,
+        description: "Report Claude Code session status to the Claude Code Status Monitor tray app"
+    }' > "$plugin_dir/.claude-plugin/plugin.json"
+
+    jq --arg hook "$HOOK_CMD" '{hooks: map_values(map(.hooks |= map(.command |= sub("__HOOK_CMD__"; $hook))))}' \
+        "$HOOKS_TEMPLATE" > "$plugin_dir/hooks/hooks.json"
+}
+
+# run_claude 在指定范围/目录下执行 claude 命令
+#   $1: scope  $2: 目录（project/local 为仓库，config_dir 为配置目录，user 为空）
+run_claude() {
+    local scope="$1" dir="$2"
+    shift 2
+    case "$scope" in
+        project|local)
+            (cd "$dir" && "$CLAUDE_BIN" "$@" --scope "$scope") ;;
+        config_dir)
+            CLAUDE_CONFIG_DIR="$dir" "$CLAUDE_BIN" "$@" --scope user ;;
+        *)
+            "$CLAUDE_BIN" "$@" --scope user ;;
+    esac
+}
+
+# with_config_dir 在 config_dir 范围下设置 CLAUDE_CONFIG_DIR 执行命令
+with_config_dir() {
+    local scope="$1" dir="$2"
+    shift 2
+    if [ "$scope" = "config_dir" ]; then
+        CLAUDE_CONFIG_DIR="$dir" "$@"
+    else
+        "$@"
+    fi
+}
+
+# remove_settings_hooks 从 settings 模式的目标文件移除 Hook（切换到插件模式时使用）
+remove_settings_hooks() {
+    local CLAUDE_SETTINGS="$1"
+    [ -f "$CLAUDE_SETTINGS" ] || return 0
+    cp "$CLAUDE_SETTINGS" "$CLAUDE_SETTINGS.backup.$(date +%Y%m%d%H%M%S)" 2>/dev/null || true
+    jq '
+        if .hooks then
+            .hooks |= with_entries(.value |= map(select(.hooks[0].command | contains("status-hook.sh") | not))) |
+            .hooks |= with_entries(select(.value != [])) |
+            if .hooks == {} then del(.hooks) else . end
+        else . end
+    ' "$CLAUDE_SETTINGS" > "$CLAUDE_SETTINGS.tmp" && mv "$CLAUDE_SETTINGS.tmp" "$CLAUDE_SETTINGS"
+}
+
+cmd_install() {
+    local scope="user" dirs=()
+    while [ $# -gt 0 ]; do
+        case "$1" in
+            --scope) scope="$2"; shift 2 ;;
+            *) dirs+=("${1/#\~/$HOME}"); shift ;;
+        esac
+    done
+    [ ${#dirs[@]} -gt 0 ] || dirs=("")
+
+    [ -f "$HOOKS_TEMPLATE" ] || die "$HOOKS_TEMPLATE not found"
+
+    # 从 settings 模式切换过来时，先移除直接写入的 Hook，避免重复触发
+    if [ -f "$INSTALL_CONF" ] && ! grep -qx 'mode=plugin' "$INSTALL_CONF"; then
+        while IFS='=' read -r key value; do
+            [ "$key" = "target" ] || continue
+            remove_settings_hooks "$value" && echo "Hook 已从 $value 移除"
+        done < "$INSTALL_CONF"
+    elif [ -f "$INSTALL_CONF" ]; then
+        # 插件模式下重装（版本更新或范围变更），先停用旧记录
+        cmd_uninstall
+    fi
+
+    write_plugin
+
+    local dir
+    for dir in "${dirs[@]}"; do
+        if [ "$scope" = "project" ] || [ "$scope" = "local" ]; then
+            [ -d "$dir" ] || die "repository not found: $dir"
+        fi
+        # marketplace 已存在时 add 会失败，此时改为 update 以刷新插件内容
+        with_config_dir "$scope" "$dir" "$CLAUDE_BIN" plugin marketplace add "$MARKETPLACE_DIR" > /dev/null 2>&1 ||
+            with_config_dir "$scope" "$dir" "$CLAUDE_BIN" plugin marketplace update "$MARKETPLACE_NAME" > /dev/null 2>&1 || true
+        run_claude "$scope" "$dir" plugin install "$PLUGIN_ID" || die "failed to install plugin ($scope ${dir:-user})"
+        echo "插件已启用: $PLUGIN_ID ($scope${dir:+ $dir})"
+    done
+
+    {
+        echo "mode=plugin"
+        echo "scope=$scope"
+        for dir in "${dirs[@]}"; do
+            [ -n "$dir" ] && echo "dir=$dir"
+        done
+    } > "$INSTALL_CONF"
+}
+
+cmd_uninstall() {
+    [ -f "$INSTALL_CONF" ] || return 0
+    local scope="user" dirs=() key value
+    while IFS='=' read -r key value; do
+        case "$key" in
+            scope) scope="$value" ;;
+            dir) dirs+=("$value") ;;
+        esac
+    done < "$INSTALL_CONF"
+    [ ${#dirs[@]} -gt 0 ] || dirs=("")
+
+    local dir
+    for dir in "${dirs[@]}"; do
+        run_claude "$scope" "$dir" plugin uninstall "$PLUGIN_ID" > /dev/null 2>&1 &&
+            echo "插件已停用: $PLUGIN_ID ($scope${dir:+ $dir})"
+        with_config_dir "$scope" "$dir" "$CLAUDE_BIN" plugin marketplace remove "$MARKETPLACE_NAME" > /dev/null 2>&1 || true
+    done
+    rm -rf "$MARKETPLACE_DIR"
+}
+
+cmd_status() {
+    "$CLAUDE_BIN" plugin list 2>/dev/null | grep -F "$PLUGIN_NAME" || echo "插件未启用"
+}
+
+sub="${1:-}"
+[ $# -gt 0 ] && shift
+case "$sub" in
+    install) cmd_install "$@" ;;
+    uninstall) cmd_uninstall ;;
+    status) cmd_status ;;
+    *) die "usage: plugin.sh install [--scope <scope>] [dir...] | uninstall | status" ;;
+esac
@@ -0,0 +1,51 @@
+#!/bin/bash
+# 监控密钥授权脚本
+# 由客户端通过 SSH（使用用户的常规密钥）执行，管理 authorized_keys 中的受限监控密钥：
+#   provision.sh add '<authorized_keys 行>'   添加或替换监控密钥
+#   provision.sh remove                       移除监控密钥
+#
+# 监控密钥行以 claude-status-monitor 注释结尾，添加前会先移除旧的同名行，保证只保留一把。
+# 该行带有 command="~/.claude-status/monitor.sh" 等限制，使用它的连接只能运行 monitor.sh
+
+set -e
+
+MARKER="claude-status-monitor"
+SSH_DIR="$HOME/.ssh"
+AUTH_KEYS="$SSH_DIR/authorized_keys"
+
+# remove_marker_lines 从 authorized_keys 中删除监控密钥行（保持文件权限不变）
+remove_marker_lines() {
+    [ -f "$AUTH_KEYS" ] || return 0
+    local tmp="$AUTH_KEYS.claude-status.$$"
+    grep -v " $MARKER\$" "$AUTH_KEYS" > "$tmp" || true
+    chmod 600 "$tmp"
+    mv -f "$tmp" "$AUTH_KEYS"
+}
+
+case "${1:-}" in
+    add)
+        line="${2:-}"
+        case "$line" in
+            *" $MARKER") ;;
+            *) echo "Error: invalid key line" >&2; exit 1 ;;
+        esac
+        mkdir -p "$SSH_DIR"
+        chmod 700 "$SSH_DIR"
+        touch "$AUTH_KEYS"
+        remove_marker_lines
+        echo "$line" >> "$AUTH_KEYS"
+        echo "监控密钥已授权: $AUTH_KEYS"
+        ;;
+    remove)
+        if [ -f "$AUTH_KEYS" ] && grep -q " $MARKER\$" "$AUTH_KEYS"; then
+            remove_marker_lines
+            echo "监控密钥已移除: $AUTH_KEYS"
+        else
+            echo "未找到监控密钥"
+        fi
+        ;;
+    *)
+        echo "usage: provision.sh add '<key line>' | remove" >&2
+        exit 1
+        ;;
+esac
@@ -0,0 +1,75 @@
+#!/bin/bash
+# 会话回复脚本：向会话所在的 tmux 窗格发送回复或按键
+# 安装到 ~/.claude-status/reply.sh，由 monitor.sh 的 reply 控制命令调用
+# （reply.sh <session_id> <tmux_pane> <action> [text]），受限监控密钥下同样可用
+#
+# 操作：
+#   approve    批准授权请求（Enter，选择默认的「Yes」），会话须处于 permission
+#   deny       拒绝授权请求（Esc），会话须处于 permission
+#   interrupt  中断正在运行的回合（Esc），会话须处于 working 或 compacting
+#   text       输入一行文字并提交（Enter），会话须处于 idle
+#
+# 发送前校验目标：<tmux_pane> 必须与 Hook 记录的窗格一致，且该窗格当前的终端与
+# Hook 记录的 tty（以及 Claude Code 进程的标准输入）一致，避免窗格关闭后编号被复用
+# 时把按键发给其他程序。成功时输出 ok。
+
+set -u
+
+SESSION_ID="${1:-}"
+PANE="${2:-}"
+ACTION="${3:-}"
+TEXT="${4:-}"
+STATUS_FILE="$HOME/.claude-status/$SESSION_ID.json"
+
+die() {
+    echo "Error: $*" >&2
+    exit 1
+}
+
+# 只匹配顶层字段（与 status-hook.sh 相同，状态文件以两个空格缩进）
+read_field() {
+    grep -oE "^  \"$1\"[[:space:]]*:[[:space:]]*(\"[^\"]*\"|[0-9]+)" "$STATUS_FILE" | head -1 | sed -E 's/.*:[[:space:]]*"?([^"]*)"?$/\1/'
+}
+
+[[ "$SESSION_ID" =~ ^[A-Za-z0-9_.-]+$ ]] || die "无效的会话 ID: $SESSION_ID"
+[ -f "$STATUS_FILE" ] || die "会话不存在: $SESSION_ID"
+command -v tmux &> /dev/null || die "服务端未安装 tmux"
+
+status=$(read_field status)
+recorded_pane=$(read_field tmux_pane)
+socket=$(read_field tmux_socket)
+tty=$(read_field tty)
+pid=$(read_field pid)
+
+[ -n "$recorded_pane" ] || die "会话未运行在 tmux 中"
+[ "$PANE" = "$recorded_pane" ] || die "会话窗格已变化（记录为 $recorded_pane），请刷新后重试"
+
+case "$ACTION" in
+    approve|deny) [ "$status" = "permission" ] || die "会话当前状态为 $status，没有待处理的授权请求" ;;
+    interrupt)    [ "$status" = "working" ] || [ "$status" = "compacting" ] || die "会话当前状态为 $status，没有正在运行的回合" ;;
+    text)
+        [ "$status" = "idle" ] || die "会话当前状态为 $status，不在等待输入"
+        TEXT="${TEXT//[$'\r\n']/ }"
+        [ -n "$TEXT" ] || die "回复内容为空"
+        ;;
+    *) die "未知操作: $ACTION" ;;
+esac
+
+TMUX_CMD=(tmux)
+[ -n "$socket" ] && TMUX_CMD+=(-S "$socket")
+
+pane_tty=$("${TMUX_CMD[@]}" display-message -p -t "$PANE" '#{pane_tty}' 2>/dev/null) || die "tmux 窗格 $PANE 不存在"
+if [ -n "$tty" ] && [ "$pane_tty" != "$tty" ]; then
+    die "窗格 $PANE 的终端 ($pane_tty) 与会话记录 ($tty) 不一致，已拒绝发送"
+fi
+if [ -n "$pid" ] && [ -d "/proc/$pid" ]; then
+    [ "$(readlink "/proc/$pid/fd/0" 2>/dev/null)" = "$pane_tty" ] || die "Claude Code 进程 $pid 不在窗格 $PANE 中，已拒绝发送"
+fi
+
+case "$ACTION" in
+    approve)        "${TMUX_CMD[@]}" send-keys -t "$PANE" Enter ;;
+    deny|interrupt) "${TMUX_CMD[@]}" send-keys -t "$PANE" Escape ;;
+    text)           "${TMUX_CMD[@]}" send-keys -t "$PANE" -l -- "$TEXT" && "${TMUX_CMD[@]}" send-keys -t "$PANE" Enter ;;
+esac || die "tmux send-keys 失败"
+
+echo "ok"
@@ -1,7 +1,45 @@
 #!/bin/bash
 # Claude Code Status Hook Script
-# 用法: status-hook.sh <working|idle|stopped>
+# 用法: status-hook.sh <working|idle|permission|compacting|notification|subagent-start|subagent-stop|stopped>
 # 由 Claude Code Hook 调用，更新状态文件
+# notification 不改变会话状态，只在状态文件中记录通知消息与类型
+# subagent-start/subagent-stop 不改变会话状态，只维护运行中的子代理列表
+# compacting 为压缩上下文期间的临时状态（PreCompact 触发），压缩完成后的 SessionStart(source=compact)
+# 按触发方式恢复：自动压缩恢复为 working，手动压缩（/compact）恢复为 idle
+#
+# 计时字段：
+#   started_at    会话首次写入状态的时间
+#   state_since   进入当前状态的时间
+#   busy_seconds  此前各段 working 状态累计的秒数（不含当前这一段）
+#
+# 工具活动字段（由 PostToolUse 触发的 working 写入）：
+#   last_tool          最近完成的工具名
+#   last_tool_summary  参数摘要：文件路径（相对项目目录）或命令首行
+#   tool_count         本轮（自上次提交提示词起）的工具调用次数
+#
+# 上下文压缩字段：
+#   compact_trigger  压缩触发方式 manual|auto（仅 compacting 状态时写入）
+#   compact_count    会话累计压缩次数
+#
+# 进程字段（供 monitor.sh 检测会话是否存活，仅 /proc 可用时写入）：
+#   pid        Claude Code 进程 PID
+#   pid_start  进程启动时间（/proc/<pid>/stat 第 22 字段），用于识别 PID 复用
+#
+# 终端字段（会话运行在 tmux/screen 中时写入，供客户端一键连接到对应窗格）：
+#   tmux_socket / tmux_pane        $TMUX 中的 socket 路径与 $TMUX_PANE（如 %3）
+#   screen_session / screen_window $STY 与 $WINDOW
+#   tty                            Claude Code 进程的终端（如 /dev/pts/3）
+#
+# Git 字段（项目目录在 Git 仓库中且有 git 命令时写入）：
+#   git_root      仓库目录（同一仓库的多个 worktree 相同）
+#   git_worktree  当前 worktree 的顶层目录
+#   git_branch    当前分支，分离 HEAD 时为短提交哈希
+#   git_dirty     已跟踪文件是否有未提交的修改
+#
+# 子代理字段：
+#   subagents  运行中的子代理 [{id, description, started_at}]。PreToolUse(Task) 时追加，
+#              SubagentStop 时移除最早的一个（SubagentStop 不携带 tool_use_id，无法精确对应，
+#              但数量始终准确）。新会话的 SessionStart（压缩完成除外）与一轮结束的 Stop 时清空，避免中断残留
 #
 # 性能优化：后台执行，立即返回，不阻塞 Claude Code
 # 性能优化：状态相同时跳过写入
@@ -16,6 +54,97 @@ _INPUT_JSON=$(timeout 0.1 cat 2>/dev/null || echo '{}')
 # 提取 session_id
 _SESSION_ID=$(echo "$_INPUT_JSON" | grep -o '"session_id"[[:space:]]*:[[:space:]]*"[^"]*"' | head -1 | sed 's/.*"\([^"]*\)"$/\1/')
 
+# 提取触发的 Hook 事件名
+_EVENT=$(echo "$_INPUT_JSON" | grep -o '"hook_event_name"[[:space:]]*:[[:space:]]*"[^"]*"' | head -1 | sed 's/.*"\([^"]*\)"$/\1/')
+
+# working 是否由 PostToolUse 触发（否则为 UserPromptSubmit，开始新一轮）
+_TOOL_CALL=""
+if [[ "$_STATUS" == "working" && "$_EVENT" == "PostToolUse" ]]; then
+    _TOOL_CALL=1
+fi
+
+# SessionStart 的来源：startup|resume|clear 为新会话，compact 表示上下文压缩完成
+_NEW_SESSION=""
+if [[ "$_EVENT" == "SessionStart" ]] && ! echo "$_INPUT_JSON" | grep -q '"source"[[:space:]]*:[[:space:]]*"compact"'; then
+    _NEW_SESSION=1
+fi
+
+# 附加字段（已转义为 JSON 字段，每个字段以逗号结尾）：
+#   permission：请求授权的工具名与参数摘要。摘要只取命令、路径、URL、模式等白名单字段，不包含文件内容
+#   notification：通知消息与类型（如 permission_prompt、idle_prompt）
+# 所有文本去除控制字符并截断
+_EXTRA_FIELDS=""
+_JQ_CLIP='def clip: gsub("[[:cntrl:]]+"; " ") | if length > 120 then .[0:117] + "..." else . end;'
+if command -v jq &> /dev/null; then
+    case "$_STATUS" in
+        permission)
+            _EXTRA_FIELDS=$(printf '%s' "$_INPUT_JSON" | jq -r "$_JQ_CLIP"'
+                (.tool_input // {}) as $in |
+                (if ($in | type) != "object" then ""
+                 else ($in.command // $in.file_path // $in.notebook_path // $in.url // $in.pattern
+                       // $in.query // $in.description // "") | tostring
+                 end) as $summary |
+                "\n  \"tool_name\": \(.tool_name // "" | tostring | clip | @json),\n  \"tool_summary\": \($summary | clip | @json),"
+            ' 2>/dev/null)
+            ;;
+        notification)
+            _EXTRA_FIELDS=$(printf '%s' "$_INPUT_JSON" | jq -r "$_JQ_CLIP"'
+                "\n  \"notification_message\": \(.message // "" | tostring | clip | @json),\n  \"notification_type\": \(.notification_type // "" | tostring | clip | @json),"
+            ' 2>/dev/null)
+            ;;
+    esac
+fi
+
+# compacting：记录压缩触发方式（取值固定，无需 jq 转义）
+if [[ "$_STATUS" == "compacting" ]]; then
+    _TRIGGER=$(echo "$_INPUT_JSON" | grep -o '"trigger"[[:space:]]*:[[:space:]]*"[^"]*"' | head -1 | sed 's/.*"\([^"]*\)"$/\1/')
+    case "$_TRIGGER" in
+        manual|auto) _EXTRA_FIELDS=$'\n  "compact_trigger": "'"$_TRIGGER"'",' ;;
+    esac
+fi
+
+# 查找 Claude Code 进程：从父进程向上跳过执行 Hook 命令的 shell
+# 必须在前台完成：Hook 返回后中间的 shell 随即退出，后台再读 /proc 会失败
+_PID_FIELDS=""
+_pid="$PPID"
+for _ in 1 2 3 4; do
+    { read -r _stat < "/proc/$_pid/stat"; } 2>/dev/null || break
+    _comm="${_stat#*(}"
+    _comm="${_comm%)*}"
+    # 去掉 "pid (comm) " 后，下标 1 为 ppid，下标 19 为 starttime
+    _rest=(${_stat##*) })
+    case "$_comm" in
+        sh|bash|dash|zsh|timeout)
+            _pid="${_rest[1]}"
+            ;;
+        *)
+            _PID_FIELDS=$'\n  "pid": '"$_pid"$',\n  "pid_start": '"${_rest[19]}"','
+            break
+            ;;
+    esac
+done
+
+# 终端上下文（已转义为 JSON 字段）：Hook 继承 Claude Code 的环境变量；tty 从进程的标准输入读取，
+# 与 PID 一样必须在前台完成
+_TERM_FIELDS=""
+_json_str() {
+    local s="$1"
+    s="${s//\\/\\\\}"
+    printf '"%s"' "${s//\"/\\\"}"
+}
+if [[ -n "$TMUX" && -n "$TMUX_PANE" ]]; then
+    _TERM_FIELDS+=$'\n  "tmux_socket": '"$(_json_str "${TMUX%%,*}")"$',\n  "tmux_pane": '"$(_json_str "$TMUX_PANE")"','
+fi
+if [[ -n "$STY" ]]; then
+    _TERM_FIELDS+=$'\n  "screen_session": '"$(_json_str "$STY")"$',\n  "screen_window": '"$(_json_str "$WINDOW")"','
+fi
+if [[ -n "$_PID_FIELDS" ]]; then
+    _tty=$(readlink "/proc/$_pid/fd/0" 2>/dev/null)
+    case "$_tty" in
+        /dev/pts/*|/dev/tty*) _TERM_FIELDS+=$'\n  "tty": '"$(_json_str "$_tty")"',' ;;
+    esac
+fi
+
 # 如果没有 session_id，输出警告并使用项目哈希作为后备
 if [[ -z "$_SESSION_ID" ]]; then
     echo "[claude-status] Warning: No session_id in hook input, using project hash fallback" >&2
@@ -30,22 +159,129 @@ fi
     # 确保状态目录存在
     [[ -d "$STATUS_DIR" ]] || mkdir -p "$STATUS_DIR"
 
+    # 串行化读-改-写：并行启动多个子代理时 Hook 会同时触发，避免互相覆盖
+    if command -v flock &> /dev/null; then
+        exec 9> "$STATUS_DIR/.hook.lock"
+        flock -w 2 9
+    fi
+
     # 使用 session_id 作为状态文件名
     STATUS_FILE="$STATUS_DIR/${_SESSION_ID}.json"
 
-    # 检查当前状态，相同则跳过写入
+    # 使用 printf 获取时间戳
+    printf -v TIMESTAMP '%(%s)T' -1
+
+    # notification：合并到已有状态文件，保留状态与其他字段；无状态文件时按 idle 新建
+    if [[ "$_STATUS" == "notification" ]]; then
+        [[ -n "$_EXTRA_FIELDS" ]] || exit 0
+        if [[ -f "$STATUS_FILE" ]]; then
+            TMP_FILE="$STATUS_FILE.tmp.$$"
+            jq --argjson n "{${_EXTRA_FIELDS%,}}" --argjson ts "$TIMESTAMP" '. + $n | .updated_at = $ts' \
+                "$STATUS_FILE" > "$TMP_FILE" 2>/dev/null && mv -f "$TMP_FILE" "$STATUS_FILE"
+            rm -f "$TMP_FILE"
+            exit 0
+        fi
+        _STATUS="idle"
+    fi
+
+    # subagent-start/subagent-stop：合并到已有状态文件，保留状态与其他字段
+    if [[ "$_STATUS" == subagent-* ]]; then
+        [[ -f "$STATUS_FILE" ]] && command -v jq &> /dev/null || exit 0
+        if [[ "$_STATUS" == "subagent-start" ]]; then
+            JQ_UPDATE='.subagents = ((.subagents // []) + [{
+                id: ($in.tool_use_id // "" | tostring),
+                description: ($in.tool_input.description // $in.tool_input.subagent_type // "" | tostring | clip),
+                started_at: $ts
+            }])'
+        else
+            JQ_UPDATE='.subagents = ((.subagents // [])[1:])'
+        fi
+        TMP_FILE="$STATUS_FILE.tmp.$$"
+        jq --argjson in "$_INPUT_JSON" --argjson ts "$TIMESTAMP" "$_JQ_CLIP $JQ_UPDATE"' |
+            (if .subagents == [] then del(.subagents) else . end) | .updated_at = $ts' \
+            "$STATUS_FILE" > "$TMP_FILE" 2>/dev/null && mv -f "$TMP_FILE" "$STATUS_FILE"
+        rm -f "$TMP_FILE"
+        exit 0
+    fi
+
+    # 工具活动：最近的工具名与参数摘要（已转义为 JSON 字段）
+    TOOL_FIELDS=""
+    if [[ -n "$_TOOL_CALL" ]] && command -v jq &> /dev/null; then
+        TOOL_FIELDS=$(printf '%s' "$_INPUT_JSON" | jq -r --arg root "$_PROJECT_DIR" "$_JQ_CLIP"'
+            (.tool_input // {}) as $in |
+            (if ($in | type) != "object" then ""
+             elif $in.command then $in.command | tostring | split("\n")[0]
+             else ($in.file_path // $in.notebook_path // $in.url // $in.pattern // $in.query // "")
+                  | tostring | ltrimstr($root + "/")
+             end) as $summary |
+            "\n  \"last_tool\": \(.tool_name // "" | tostring | clip | @json),\n  \"last_tool_summary\": \($summary | clip | @json),"
+        ' 2>/dev/null)
+    fi
+
+    # 读取上一次的状态与计时字段（简单提取，避免依赖 jq）
+    # 只匹配顶层字段（printf 与 jq 均以两个空格缩进），避免误取 subagents 中的同名字段
+    read_field() {
+        grep -oE "^  \"$1\"[[:space:]]*:[[:space:]]*(\"[^\"]*\"|[0-9]+)" "$STATUS_FILE" | head -1 | sed -E 's/.*:[[:space:]]*"?([^"]*)"?$/\1/'
+    }
+    current_status=""
+    STARTED_AT="$TIMESTAMP"
+    STATE_SINCE="$TIMESTAMP"
+    BUSY_SECONDS=0
+    TOOL_COUNT=0
+    COMPACT_COUNT=0
     if [[ -f "$STATUS_FILE" ]]; then
-        # 简单提取 status 字段值（避免依赖 jq）
-        current_status=$(grep -o '"status"[[:space:]]*:[[:space:]]*"[^"]*"' "$STATUS_FILE" | head -1 | sed 's/.*"\([^"]*\)"$/\1/')
-        [[ "$current_status" == "$_STATUS" ]] && exit 0
+        current_status=$(read_field status)
+        prev_started=$(read_field started_at)
+        prev_since=$(read_field state_since)
+        prev_busy=$(read_field busy_seconds)
+        [[ -n "$prev_started" ]] && STARTED_AT="$prev_started"
+        [[ -n "$prev_busy" ]] && BUSY_SECONDS="$prev_busy"
+        prev_count=$(read_field tool_count)
+        [[ -n "$prev_count" ]] && TOOL_COUNT="$prev_count"
+        prev_compacts=$(read_field compact_count)
+        [[ -n "$prev_compacts" ]] && COMPACT_COUNT="$prev_compacts"
+        prev_trigger=$(read_field compact_trigger)
+    fi
+
+    # 压缩完成：自动压缩发生在回合中途，恢复为 working
+    if [[ "$_EVENT" == "SessionStart" && -z "$_NEW_SESSION" && "$current_status" == "compacting" \
+        && "$prev_trigger" == "auto" ]]; then
+        _STATUS="working"
+    fi
+    [[ "$_STATUS" == "compacting" ]] && COMPACT_COUNT=$((COMPACT_COUNT + 1))
+
+    if [[ -n "$_TOOL_CALL" ]]; then
+        TOOL_COUNT=$((TOOL_COUNT + 1))
+    elif [[ "$_EVENT" == "UserPromptSubmit" ]]; then
+        # 新一轮提示词：重新计数
+        TOOL_COUNT=0
+    fi
+
+    # 已有运行中的子代理（新会话时不沿用）
+    HAS_SUBAGENTS=""
+    [[ -z "$_NEW_SESSION" && -f "$STATUS_FILE" ]] && grep -q '"subagents"' "$STATUS_FILE" && HAS_SUBAGENTS=1
+
+    if [[ "$current_status" == "$_STATUS" ]]; then
+        # 状态相同则跳过写入（permission 每次请求的工具不同、PostToolUse 需要更新工具活动、
+        # compacting 需要计数、SessionStart 与带子代理的 Stop 需要清空子代理，这些情况始终写入，但保留进入时间）
+        [[ "$_STATUS" != "permission" && "$_STATUS" != "compacting" && -z "$_TOOL_CALL" \
+            && "$_EVENT" != "SessionStart" && ! ( "$_EVENT" == "Stop" && -n "$HAS_SUBAGENTS" ) ]] && exit 0
+        [[ -n "$prev_since" ]] && STATE_SINCE="$prev_since"
+    elif [[ "$current_status" == "working" && -n "$prev_since" ]]; then
+        # 离开 working：累计本段运行时长
+        BUSY_SECONDS=$((BUSY_SECONDS + TIMESTAMP - prev_since))
+    fi
+
+    # 保留运行中的子代理（新会话时清空；Stop 时一轮已结束，子代理不会再运行，未收到的 SubagentStop
+    # 不再等待，一并清空）
+    SUBAGENT_FIELDS=""
+    if [[ -n "$HAS_SUBAGENTS" && "$_EVENT" != "Stop" ]] && command -v jq &> /dev/null; then
+        SUBAGENT_FIELDS=$(jq -r '"\n  \"subagents\": \(.subagents // [] | tojson),"' "$STATUS_FILE" 2>/dev/null)
     fi
 
     # 使用 bash 内置的字符串操作替代 basename
     PROJECT_NAME="${_PROJECT_DIR##*/}"
 
-    # 使用 printf 获取时间戳
-    printf -v TIMESTAMP '%(%s)T' -1
-
     # JSON 转义函数
     json_escape() {
         local s="$1"
@@ -54,6 +290,30 @@ fi
         printf '%s' "$s"
     }
 
+    # Git 上下文（已转义为 JSON 字段）。GIT_OPTIONAL_LOCKS=0：git status 不刷新索引，
+    # 避免与 Claude Code 自身的 git 操作争用 index.lock。git 命令只在一轮开始/结束、会话开始或状态变化时
+    # 执行，同一状态下的工具调用（PostToolUse 等）沿用上次写入的字段，避免每次工具调用都启动多个 git 进程
+    GIT_FIELDS=""
+    if [[ -z "$_NEW_SESSION" && -f "$STATUS_FILE" && "$current_status" == "$_STATUS" \
+        && "$_EVENT" != "UserPromptSubmit" && "$_EVENT" != "Stop" && "$_EVENT" != "SessionStart" ]]; then
+        while IFS= read -r git_line; do
+            GIT_FIELDS+=$'\n'"${git_line%,},"
+        done < <(grep -E '^  "git_(root|worktree|branch|dirty)"[[:space:]]*:' "$STATUS_FILE")
+    elif command -v git &> /dev/null \
+        && GIT_WORKTREE=$(git -C "$_PROJECT_DIR" rev-parse --show-toplevel 2>/dev/null); then
+        # --git-common-dir 可能是相对路径（相对项目目录），主 worktree 为 <仓库>/.git
+        GIT_COMMON=$(cd "$_PROJECT_DIR" 2>/dev/null && cd "$(git rev-parse --git-common-dir 2>/dev/null)" 2>/dev/null && pwd -P)
+        GIT_ROOT="${GIT_COMMON%/.git}"
+        [[ -n "$GIT_ROOT" ]] || GIT_ROOT="$GIT_WORKTREE"
+        GIT_BRANCH=$(git -C "$_PROJECT_DIR" symbolic-ref --short -q HEAD 2>/dev/null \
+            || git -C "$_PROJECT_DIR" rev-parse --short HEAD 2>/dev/null)
+        GIT_DIRTY=false
+        [[ -n "$(GIT_OPTIONAL_LOCKS=0 git -C "$_PROJECT_DIR" status --porcelain --untracked-files=no 2>/dev/null | head -1)" ]] \
+            && GIT_DIRTY=true
+        GIT_FIELDS=$(printf '\n  "git_root": "%s",\n  "git_worktree": "%s",\n  "git_branch": "%s",\n  "git_dirty": %s,' \
+            "$(json_escape "$GIT_ROOT")" "$(json_escape "$GIT_WORKTREE")" "$(json_escape "$GIT_BRANCH")" "$GIT_DIRTY")
+    fi
+
     PROJECT_DIR_ESCAPED=$(json_escape "$_PROJECT_DIR")
     PROJECT_NAME_ESCAPED=$(json_escape "$PROJECT_NAME")
     SESSION_ID_ESCAPED=$(json_escape "$_SESSION_ID")
@@ -64,10 +324,16 @@ fi
   "project": "%s",
   "project_name": "%s",
   "session_id": "%s",
-  "status": "%s",
+  "status": "%s",%s%s%s%s%s%s
+  "tool_count": %s,
+  "compact_count": %s,
+  "started_at": %s,
+  "state_since": %s,
+  "busy_seconds": %s,
   "updated_at": %s
 }
-' "$PROJECT_DIR_ESCAPED" "$PROJECT_NAME_ESCAPED" "$SESSION_ID_ESCAPED" "$_STATUS" "$TIMESTAMP" > "$TMP_FILE" && mv -f "$TMP_FILE" "$STATUS_FILE"
+' "$PROJECT_DIR_ESCAPED" "$PROJECT_NAME_ESCAPED" "$SESSION_ID_ESCAPED" "$_STATUS" "$GIT_FIELDS" "$_EXTRA_FIELDS" "$TOOL_FIELDS" "$SUBAGENT_FIELDS" "$_PID_FIELDS" "$_TERM_FIELDS" \
+    "$TOOL_COUNT" "$COMPACT_COUNT" "$STARTED_AT" "$STATE_SINCE" "$BUSY_SECONDS" "$TIMESTAMP" > "$TMP_FILE" && mv -f "$TMP_FILE" "$STATUS_FILE"
 ) &
 
 exit 0
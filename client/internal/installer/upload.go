@@ -0,0 +1,194 @@
+//go:build windows
+
+package installer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"claude-status/internal/config"
+	"claude-status/internal/logger"
+	"claude-status/internal/monitor"
+
+	"github.com/pkg/sftp"
+)
+
+// RemoteFile 需要上传到服务端的文件
+type RemoteFile struct {
+	Path    string      // 远程路径，以 ~/ 开头
+	Content string      // 文件内容
+	Mode    os.FileMode // 文件权限
+}
+
+// RemoteFiles 返回安装时需要上传的全部文件（SSH 与 WSL 共用）
+func RemoteFiles() []RemoteFile {
+	return []RemoteFile{
+		{"~/.claude-status/hooks/status-hook.sh", StatusHookScript, 0755},
+		{"~/.claude-status/monitor.sh", MonitorScript, 0755},
+		{"~/.claude-status/hooks/hooks.json", HooksTemplate, 0644},
+		{"~/.claude-status/plugin.sh", PluginScript, 0755},
+		{"~/.claude-status/reply.sh", ReplyScript, 0755},
+	}
+}
+
+// ConfigFiles 返回按客户端配置生成、随安装一并上传的文件（服务端通知配置含密码，仅本人可读）
+func ConfigFiles(cfg *config.Config) []RemoteFile {
+	return []RemoteFile{
+		{"~/.claude-status/notify.conf", NotifyConf(cfg), 0600},
+	}
+}
+
+// HomeRelative 去掉远程路径的 ~/ 前缀，返回相对于用户主目录的路径
+func HomeRelative(remotePath string) string {
+	return strings.TrimPrefix(remotePath, "~/")
+}
+
+// Checksum 返回内容的 sha256 十六进制摘要
+func Checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// AtomicWriteCommand 构造从 stdin 原子写入文件的 bash 脚本：
+// 先写入同目录临时文件并 fsync，校验 sha256 与权限后再 rename 覆盖目标，
+// 任何一步失败都会删除临时文件，目标文件保持原样。
+// remotePath 为相对主目录的路径（可带 ~/ 前缀），由 ShellQuote 转义，可包含空格等特殊字符。
+func AtomicWriteCommand(remotePath string, mode os.FileMode, checksum string) string {
+	return fmt.Sprintf(`set -e
+f="$HOME"/%s
+tmp="$f.tmp.$$"
+trap 'rm -f "$tmp"' EXIT
+cat > "$tmp"
+sync "$tmp" 2>/dev/null || sync
+if command -v sha256sum > /dev/null 2>&1; then sum=$(sha256sum < "$tmp"); else sum=$(shasum -a 256 < "$tmp"); fi
+if [ "${sum%%%% *}" != %s ]; then echo "checksum mismatch: $f" >&2; exit 1; fi
+chmod %o "$tmp"
+mv -f "$tmp" "$f"
+trap - EXIT`, monitor.ShellQuote(HomeRelative(remotePath)), monitor.ShellQuote(checksum), mode.Perm())
+}
+
+// uploadFiles 上传文件：优先使用 SFTP，服务端未启用 SFTP 子系统或不支持 posix-rename 时回退到 shell 写入。
+// 两种方式均为 写临时文件 → fsync → 设置权限 → 校验 sha256 → rename 覆盖，
+// 连接中断不会留下截断的脚本。
+func (i *Installer) uploadFiles(files []RemoteFile) error {
+	client, err := sftp.NewClient(i.client)
+	if err != nil {
+		logger.Info("SFTP 不可用，使用 shell 写入: %v", err)
+		return i.uploadFilesShell(files)
+	}
+	defer client.Close()
+
+	// 标准 SFTP rename 不覆盖已有文件，先删除再重命名会在中断时丢失目标，改用 shell 的 mv -f
+	if _, ok := client.HasExtension("posix-rename@openssh.com"); !ok {
+		logger.Info("SFTP 不支持 posix-rename，使用 shell 写入")
+		return i.uploadFilesShell(files)
+	}
+
+	for _, f := range files {
+		if err := uploadFileSFTP(client, f.Path, f.Content, f.Mode); err != nil {
+			return fmt.Errorf("上传 %s 失败: %w", path.Base(f.Path), err)
+		}
+	}
+	return nil
+}
+
+// uploadFileSFTP 通过 SFTP 原子写入文件（需要 posix-rename 扩展覆盖目标）
+func uploadFileSFTP(client *sftp.Client, remotePath, content string, mode os.FileMode) error {
+	home, err := client.Getwd()
+	if err != nil {
+		return fmt.Errorf("获取远程主目录失败: %w", err)
+	}
+	target := path.Join(home, HomeRelative(remotePath))
+	tmp := fmt.Sprintf("%s.tmp.%d", target, time.Now().UnixNano())
+
+	if err := writeTempSFTP(client, tmp, content, mode); err != nil {
+		client.Remove(tmp)
+		return err
+	}
+
+	// 上传后校验：读回临时文件比对 sha256，通过后再替换目标
+	if err := verifySFTP(client, tmp, Checksum(content)); err != nil {
+		client.Remove(tmp)
+		return err
+	}
+
+	if err := client.PosixRename(tmp, target); err != nil {
+		client.Remove(tmp)
+		return fmt.Errorf("重命名 %s 失败: %w", target, err)
+	}
+	return nil
+}
+
+// writeTempSFTP 写入临时文件、fsync 并设置权限
+func writeTempSFTP(client *sftp.Client, tmp, content string, mode os.FileMode) error {
+	f, err := client.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.WriteString(f, content); err != nil {
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	if _, ok := client.HasExtension("fsync@openssh.com"); ok {
+		if err := f.Sync(); err != nil {
+			return fmt.Errorf("fsync 失败: %w", err)
+		}
+	} else {
+		logger.Info("SFTP 不支持 fsync，跳过 %s 的 fsync", path.Base(tmp))
+	}
+	if err := f.Chmod(mode); err != nil {
+		return fmt.Errorf("设置权限失败: %w", err)
+	}
+	return f.Close()
+}
+
+// verifySFTP 读回远程文件并校验 sha256
+func verifySFTP(client *sftp.Client, remotePath, checksum string) error {
+	f, err := client.Open(remotePath)
+	if err != nil {
+		return fmt.Errorf("读取校验失败: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("读取校验失败: %w", err)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != checksum {
+		return fmt.Errorf("校验失败: %s sha256 不一致", remotePath)
+	}
+	return nil
+}
+
+// uploadFilesShell 逐个通过 shell 写入文件
+func (i *Installer) uploadFilesShell(files []RemoteFile) error {
+	for _, f := range files {
+		if err := i.uploadFileShell(f.Path, f.Content, f.Mode); err != nil {
+			return fmt.Errorf("上传 %s 失败: %w", path.Base(f.Path), err)
+		}
+	}
+	return nil
+}
+
+// uploadFileShell 通过 shell 原子写入文件（SFTP 不可用时的回退）
+func (i *Installer) uploadFileShell(remotePath, content string, mode os.FileMode) error {
+	session, err := i.client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	session.Stdin = strings.NewReader(content)
+	output, err := session.CombinedOutput("bash -c " + monitor.ShellQuote(AtomicWriteCommand(remotePath, mode, Checksum(content))))
+	if err != nil {
+		return fmt.Errorf("%w (output: %s)", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package monitor
+
+// pathTools 参数摘要为文件路径的工具，显示为 "Edit src/foo.go"；其余显示为 "Bash: go test ./..."
+var pathTools = map[string]bool{
+	"Read":         true,
+	"Edit":         true,
+	"MultiEdit":    true,
+	"Write":        true,
+	"NotebookEdit": true,
+}
+
+// ActivityLabel 返回运行中会话最近的工具活动，如 "Edit src/foo.go"、"Bash: go test ./..."；
+// 非 working 状态或未记录工具时返回空串
+func (s ProjectStatus) ActivityLabel() string {
+	if s.Status != StatusWorking || s.LastTool == "" {
+		return ""
+	}
+	switch {
+	case s.LastToolSummary == "":
+		return s.LastTool
+	case pathTools[s.LastTool]:
+		return s.LastTool + " " + s.LastToolSummary
+	default:
+		return s.LastTool + ": " + s.LastToolSummary
+	}
+}
@@ -0,0 +1,22 @@
+package monitor
+
+import "testing"
+
+func TestActivityLabel(t *testing.T) {
+	tests := []struct {
+		s    ProjectStatus
+		want string
+	}{
+		{ProjectStatus{Status: StatusWorking, LastTool: "Edit", LastToolSummary: "src/foo.go"}, "Edit src/foo.go"},
+		{ProjectStatus{Status: StatusWorking, LastTool: "Bash", LastToolSummary: "go test ./..."}, "Bash: go test ./..."},
+		{ProjectStatus{Status: StatusWorking, LastTool: "TodoWrite"}, "TodoWrite"},
+		{ProjectStatus{Status: StatusWorking}, ""},
+		{ProjectStatus{Status: StatusIdle, LastTool: "Edit", LastToolSummary: "a.go"}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := tt.s.ActivityLabel(); got != tt.want {
+			t.Errorf("ActivityLabel(%+v) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package monitor
+
+// AttachCommand 返回在服务端连接到会话所在 tmux 窗格或 screen 窗口的 shell 命令
+// （需要交互式终端，如 ssh -t）；会话不在 tmux/screen 中时返回空串
+func (s ProjectStatus) AttachCommand() string {
+	switch {
+	case s.TmuxPane != "":
+		tmux := "tmux"
+		if s.TmuxSocket != "" {
+			tmux += " -S " + ShellQuote(s.TmuxSocket)
+		}
+		pane := ShellQuote(s.TmuxPane)
+		// 先切到窗格所在的窗口与窗格；已在 tmux 中时切换当前客户端，否则新建客户端连接
+		return tmux + " select-window -t " + pane + " \\; select-pane -t " + pane +
+			" && if [ -n \"$TMUX\" ]; then " + tmux + " switch-client -t " + pane +
+			"; else " + tmux + " attach-session -t " + pane + "; fi"
+	case s.ScreenSession != "":
+		cmd := "screen -x " + ShellQuote(s.ScreenSession)
+		if s.ScreenWindow != "" {
+			cmd += " -p " + ShellQuote(s.ScreenWindow)
+		}
+		return cmd
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,22 @@
+package monitor
+
+import "testing"
+
+func TestAttachCommand(t *testing.T) {
+	tmux := ProjectStatus{TmuxSocket: "/tmp/tmux-1000/default", TmuxPane: "%3"}
+	want := `tmux -S '/tmp/tmux-1000/default' select-window -t '%3' \; select-pane -t '%3' && ` +
+		`if [ -n "$TMUX" ]; then tmux -S '/tmp/tmux-1000/default' switch-client -t '%3'; ` +
+		`else tmux -S '/tmp/tmux-1000/default' attach-session -t '%3'; fi`
+	if got := tmux.AttachCommand(); got != want {
+		t.Errorf("tmux AttachCommand =\n%s\nwant\n%s", got, want)
+	}
+
+	screen := ProjectStatus{ScreenSession: "1234.pts-0.host", ScreenWindow: "2"}
+	if got := screen.AttachCommand(); got != "screen -x '1234.pts-0.host' -p '2'" {
+		t.Errorf("screen AttachCommand = %q", got)
+	}
+
+	if got := (ProjectStatus{Tty: "/dev/pts/1"}).AttachCommand(); got != "" {
+		t.Errorf("plain terminal AttachCommand = %q, want empty", got)
+	}
+}
@@ -0,0 +1,65 @@
+package monitor
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// SkewWarnThreshold 服务端与客户端时钟偏差超过该值时在 doctor 与托盘提示中警告
+const SkewWarnThreshold = time.Minute
+
+// skewResetThreshold 新样本与当前估计相差超过该值时直接采用（休眠唤醒、校时后的跳变），不做平滑
+const skewResetThreshold = 30 * time.Second
+
+// skewSmoothing 指数平滑系数：服务端时间只精确到秒，单个样本另含单程网络延迟
+const skewSmoothing = 0.2
+
+// ClockSkew 根据服务端消息携带的当前时间（StatusMessage.Now）估计服务端时钟相对客户端的偏差
+// （服务端 - 客户端），对样本做指数平滑。零值可用，可被多个 goroutine 同时使用
+type ClockSkew struct {
+	mu     sync.Mutex
+	offset float64 // 秒
+	valid  bool
+}
+
+// Observe 记录一个样本：serverNow 为服务端发送消息时的 Unix 时间，received 为客户端收到的时间
+func (c *ClockSkew) Observe(serverNow int64, received time.Time) {
+	// 服务端时间截断到整秒，按半秒补偿
+	sample := float64(serverNow) + 0.5 - float64(received.UnixNano())/1e9
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.valid || math.Abs(sample-c.offset) > skewResetThreshold.Seconds() {
+		c.offset = sample
+		c.valid = true
+		return
+	}
+	c.offset += skewSmoothing * (sample - c.offset)
+}
+
+// Offset 返回平滑后的偏差（精确到毫秒），尚无样本时为 0
+func (c *ClockSkew) Offset() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Duration(math.Round(c.offset*1000)) * time.Millisecond
+}
+
+// ServerNow 返回按偏差换算的服务端当前 Unix 时间，用于与服务端记录的时间戳比较
+func ServerNow(skew time.Duration) int64 {
+	return time.Now().Add(skew).Unix()
+}
+
+// SkewLabel 描述时钟偏差，如 "服务端时钟快 3m"、"服务端时钟慢 45s"
+func SkewLabel(skew time.Duration) string {
+	secs := int64(skew.Round(time.Second) / time.Second)
+	if secs >= 0 {
+		return "服务端时钟快 " + FormatDuration(secs)
+	}
+	return "服务端时钟慢 " + FormatDuration(-secs)
+}
+
+// SkewExceeded 偏差是否超过警告阈值
+func SkewExceeded(skew time.Duration) bool {
+	return skew > SkewWarnThreshold || skew < -SkewWarnThreshold
+}
@@ -0,0 +1,52 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockSkew(t *testing.T) {
+	var c ClockSkew
+	if c.Offset() != 0 {
+		t.Fatalf("zero value offset = %s, want 0", c.Offset())
+	}
+
+	base := time.Unix(1700000000, 0)
+	// 服务端快 120 秒：首个样本直接采用
+	c.Observe(base.Unix()+120, base)
+	if got := c.Offset(); got != 120500*time.Millisecond {
+		t.Fatalf("first sample: offset = %s, want 2m0.5s", got)
+	}
+
+	// 小幅抖动被平滑
+	c.Observe(base.Unix()+125, base)
+	if got := c.Offset(); got <= 120500*time.Millisecond || got >= 125500*time.Millisecond {
+		t.Errorf("smoothed offset = %s, want between samples", got)
+	}
+
+	// 跳变（如休眠唤醒后校时）直接采用
+	c.Observe(base.Unix()-10, base)
+	if got := c.Offset(); got != -9500*time.Millisecond {
+		t.Errorf("after jump: offset = %s, want -9.5s", got)
+	}
+}
+
+func TestSkewLabel(t *testing.T) {
+	cases := []struct {
+		skew time.Duration
+		want string
+		warn bool
+	}{
+		{3*time.Minute + 12*time.Second, "服务端时钟快 3m", true},
+		{-45 * time.Second, "服务端时钟慢 45s", false},
+		{-2 * time.Minute, "服务端时钟慢 2m", true},
+	}
+	for _, c := range cases {
+		if got := SkewLabel(c.skew); got != c.want {
+			t.Errorf("SkewLabel(%s) = %q, want %q", c.skew, got, c.want)
+		}
+		if got := SkewExceeded(c.skew); got != c.warn {
+			t.Errorf("SkewExceeded(%s) = %v, want %v", c.skew, got, c.warn)
+		}
+	}
+}
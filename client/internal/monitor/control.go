@@ -0,0 +1,132 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 控制命令（客户端经监控会话的 stdin 逐行发送 "<id> <command> [args...]"，
+// 服务端以 type=response 的消息回复，协议见 monitor.sh）
+const (
+	CmdPing          = "ping"           // 立即回复，用于测量往返延迟
+	CmdResync        = "resync"         // 重新扫描并推送完整状态与用量窗口
+	CmdSubscribe     = "subscribe"      // 只推送指定项目（目录或项目名）的会话，无参数时取消过滤
+	CmdSetRetention  = "set-retention"  // 修改保留策略：未结束与已结束会话的保留时长（秒，至少 60）、是否归档（0/1）
+	CmdSetDebounce   = "set-debounce"   // 修改服务端合并状态变化的窗口（毫秒，0 到 5000，0 关闭）
+	CmdDeleteSession = "delete-session" // 删除会话的状态文件
+	CmdLease         = "lease"          // 客户端租约（秒），有效期间服务端不推送通知
+	CmdReplay        = "replay"         // 补发事件日志中指定位置之后的事件，回复当前位置（见 JournalPosition）
+	CmdReply         = "reply"          // 经 reply.sh 向会话的 tmux 窗格发送回复（见 ReplyRequest）
+)
+
+// ControlTimeout 等待控制命令回复的时长
+const ControlTimeout = 10 * time.Second
+
+// ErrControlClosed 控制通道已关闭（连接断开）
+var ErrControlClosed = errors.New("控制通道已关闭")
+
+var commandPattern = regexp.MustCompile(`^[a-z-]+$`)
+
+// Control 控制通道：为每条命令分配递增 ID 写入服务端 stdin，
+// 并把服务端的回复按 ID 交给等待的请求。可被多个 goroutine 同时使用
+type Control struct {
+	mu      sync.Mutex
+	w       io.Writer
+	nextId  int64
+	pending map[int64]chan StatusMessage
+	closed  bool
+}
+
+// NewControl 创建写入 w 的控制通道
+func NewControl(w io.Writer) *Control {
+	return &Control{w: w, pending: make(map[int64]chan StatusMessage)}
+}
+
+// Request 发送命令并在 timeout 内等待回复，返回回复的消息；
+// 服务端回复 ok=false 时以其消息作为错误返回。参数不能为空或包含空白字符
+func (c *Control) Request(timeout time.Duration, command string, args ...string) (string, error) {
+	if !commandPattern.MatchString(command) {
+		return "", fmt.Errorf("无效的控制命令: %q", command)
+	}
+	for _, a := range args {
+		if a == "" || strings.ContainsAny(a, " \t\r\n") {
+			return "", fmt.Errorf("控制命令 %s 的参数不能为空或包含空白字符: %q", command, a)
+		}
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return "", ErrControlClosed
+	}
+	c.nextId++
+	id := c.nextId
+	ch := make(chan StatusMessage, 1)
+	c.pending[id] = ch
+	line := strings.Join(append([]string{fmt.Sprint(id), command}, args...), " ") + "\n"
+	_, err := io.WriteString(c.w, line)
+	if err != nil {
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return "", fmt.Errorf("发送控制命令失败: %w", err)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case msg, ok := <-ch:
+		if !ok {
+			return "", ErrControlClosed
+		}
+		if !msg.Ok {
+			return "", fmt.Errorf("%s", msg.Message)
+		}
+		return msg.Message, nil
+	case <-timer.C:
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return "", fmt.Errorf("控制命令 %s 超时（%s 内未收到回复）", command, timeout)
+	}
+}
+
+// Deliver 把 type=response 的消息交给对应的请求；没有等待方（如已超时）时忽略
+func (c *Control) Deliver(msg StatusMessage) {
+	c.mu.Lock()
+	ch, ok := c.pending[msg.Id]
+	delete(c.pending, msg.Id)
+	c.mu.Unlock()
+	if ok {
+		ch <- msg
+	}
+}
+
+// Close 关闭控制通道，等待中的请求返回 ErrControlClosed，之后的请求直接失败
+func (c *Control) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+}
+
+// Ping 经客户端的控制通道发送 ping，返回往返延迟
+func Ping(c Client) (time.Duration, error) {
+	start := time.Now()
+	if _, err := c.Request(CmdPing); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
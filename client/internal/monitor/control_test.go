@@ -0,0 +1,57 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// echoServer 模拟服务端：收到命令后按 ID 回复，fail 命令回复失败，hang 命令不回复
+type echoServer struct{ c *Control }
+
+func (s *echoServer) Write(p []byte) (int, error) {
+	var id int64
+	var command string
+	fmt.Sscan(string(p), &id, &command)
+	if command != "hang" {
+		msg := StatusMessage{Type: MsgTypeResponse, Id: id, Command: command, Ok: command != "fail",
+			Message: strings.TrimSpace(string(p))}
+		go s.c.Deliver(msg)
+	}
+	return len(p), nil
+}
+
+func TestControlRequest(t *testing.T) {
+	srv := &echoServer{}
+	c := NewControl(srv)
+	srv.c = c
+
+	if got, err := c.Request(time.Second, CmdSubscribe, "/src/a", "b"); err != nil || got != "1 subscribe /src/a b" {
+		t.Errorf("subscribe: got %q, %v", got, err)
+	}
+	if _, err := c.Request(time.Second, "fail"); err == nil || err.Error() != "2 fail" {
+		t.Errorf("fail: got %v, want server message as error", err)
+	}
+	if _, err := c.Request(time.Second, CmdDeleteSession, "a b"); err == nil {
+		t.Error("argument with space: want error")
+	}
+	if _, err := c.Request(10*time.Millisecond, "hang"); err == nil {
+		t.Error("hang: want timeout")
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := c.Request(time.Minute, "hang")
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	c.Close()
+	if err := <-done; !errors.Is(err, ErrControlClosed) {
+		t.Errorf("pending after close: got %v", err)
+	}
+	if _, err := c.Request(time.Second, CmdPing); !errors.Is(err, ErrControlClosed) {
+		t.Errorf("after close: got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package monitor
+
+import "strings"
+
+// RepoName 返回仓库名（同一仓库的多个 worktree 相同），不在 Git 仓库中时返回项目名
+func (s ProjectStatus) RepoName() string {
+	if s.GitRoot == "" {
+		return s.ProjectName
+	}
+	name := s.GitRoot[strings.LastIndex(s.GitRoot, "/")+1:]
+	return strings.TrimSuffix(name, ".git")
+}
+
+// RepoKey 返回仓库的唯一标识，不在 Git 仓库中时为项目目录
+func (s ProjectStatus) RepoKey() string {
+	if s.GitRoot == "" {
+		return s.Project
+	}
+	return s.GitRoot
+}
+
+// WorktreeKey 返回悬浮窗的分组键：同一 worktree（不论子目录）的会话合并为一组，
+// 不在 Git 仓库中时按项目目录
+func (s ProjectStatus) WorktreeKey() string {
+	if s.GitWorktree == "" {
+		return s.Project
+	}
+	return s.GitWorktree
+}
+
+// BranchLabel 返回分支名，有未提交的修改时追加 "*"；无分支信息时返回空串
+func (s ProjectStatus) BranchLabel() string {
+	if s.GitBranch == "" || !s.GitDirty {
+		return s.GitBranch
+	}
+	return s.GitBranch + "*"
+}
+
+// DisplayName 返回带分支的显示名，如 "claude-status · feat-a*"
+func (s ProjectStatus) DisplayName() string {
+	if branch := s.BranchLabel(); branch != "" {
+		return s.RepoName() + " · " + branch
+	}
+	return s.RepoName()
+}
@@ -0,0 +1,22 @@
+package monitor
+
+import "testing"
+
+func TestDisplayName(t *testing.T) {
+	tests := []struct {
+		s    ProjectStatus
+		want string
+	}{
+		{ProjectStatus{ProjectName: "scratch"}, "scratch"},
+		{ProjectStatus{ProjectName: "sub", GitRoot: "/src/app", GitBranch: "main"}, "app · main"},
+		{ProjectStatus{ProjectName: "wt-a", GitRoot: "/src/app.git", GitBranch: "feat-a", GitDirty: true}, "app · feat-a*"},
+		// 无 Hook 的会话只有分支
+		{ProjectStatus{ProjectName: "app", GitBranch: "fix"}, "app · fix"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.s.DisplayName(); got != tt.want {
+			t.Errorf("DisplayName(%+v) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JournalEvent 服务端事件日志（~/.claude-status/journal）中的一次会话状态变化，
+// From 为空表示会话新出现。服务器重启后日志保留，序号在同一日志内单调递增
+type JournalEvent struct {
+	Seq         int64  `json:"seq"`
+	Time        int64  `json:"time"`
+	SessionId   string `json:"session_id"`
+	Project     string `json:"project"`
+	ProjectName string `json:"project_name"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+
+	Journal string `json:"-"` // 所属事件日志的 ID，来自消息
+	Replay  bool   `json:"-"` // 是否为重连后补发的历史事件
+}
+
+// EventBuffer 客户端事件 channel 的容量，已满时丢弃新事件（只影响离线汇总与位置更新的及时性）
+const EventBuffer = 1024
+
+// JournalPosition 客户端在事件日志中的位置：已收到序号不超过 Seq 的全部事件
+type JournalPosition struct {
+	Journal string `json:"journal"`
+	Seq     int64  `json:"seq"`
+}
+
+// ParseJournalPosition 解析 replay 命令回复的 "<日志 ID> <序号>"
+func ParseJournalPosition(s string) (JournalPosition, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return JournalPosition{}, fmt.Errorf("无效的事件日志位置: %q", s)
+	}
+	seq, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || seq < 0 {
+		return JournalPosition{}, fmt.Errorf("无效的事件序号: %q", fields[1])
+	}
+	return JournalPosition{Journal: fields[0], Seq: seq}, nil
+}
+
+// Advance 收到事件后更新位置，返回位置是否变化；其他日志的事件（日志已重建）从该事件重新开始
+func (p *JournalPosition) Advance(e JournalEvent) bool {
+	if !p.After(e) {
+		return false
+	}
+	p.Journal, p.Seq = e.Journal, e.Seq
+	return true
+}
+
+// After 事件是否位于该位置之后（日志 ID 不同时视为之后：日志已重建，序号从头开始）
+func (p JournalPosition) After(e JournalEvent) bool {
+	return e.Journal != p.Journal || e.Seq > p.Seq
+}
+
+// StatusLabel 返回会话状态的中文名称
+func StatusLabel(status string) string {
+	switch status {
+	case StatusWorking:
+		return "运行中"
+	case StatusIdle:
+		return "等待输入"
+	case StatusPermission:
+		return "等待授权"
+	case StatusCompacting:
+		return "压缩上下文"
+	case StatusStopped:
+		return "已结束"
+	case "":
+		return "新会话"
+	default:
+		return status
+	}
+}
@@ -0,0 +1,42 @@
+package monitor
+
+import "testing"
+
+func TestJournalPosition(t *testing.T) {
+	pos, err := ParseJournalPosition("j1 42")
+	if err != nil || pos != (JournalPosition{Journal: "j1", Seq: 42}) {
+		t.Fatalf("parse: got %+v, %v", pos, err)
+	}
+	for _, s := range []string{"", "j1", "j1 x", "j1 -1", "j1 1 2"} {
+		if _, err := ParseJournalPosition(s); err == nil {
+			t.Errorf("parse %q: want error", s)
+		}
+	}
+
+	if pos.Advance(JournalEvent{Journal: "j1", Seq: 40}) || pos.Seq != 42 {
+		t.Errorf("older event advanced position to %+v", pos)
+	}
+	if !pos.Advance(JournalEvent{Journal: "j1", Seq: 43}) || pos.Seq != 43 {
+		t.Errorf("newer event: got %+v", pos)
+	}
+	// 日志重建后序号从头开始
+	if !pos.Advance(JournalEvent{Journal: "j2", Seq: 1}) || pos != (JournalPosition{Journal: "j2", Seq: 1}) {
+		t.Errorf("new journal: got %+v", pos)
+	}
+}
+
+func TestJournalPositionAfter(t *testing.T) {
+	pos := JournalPosition{Journal: "j1", Seq: 2}
+	// 以当前日志 ID 与序号重连时补发的事件都不在位置之后
+	for _, seq := range []int64{1, 2} {
+		if pos.After(JournalEvent{Journal: "j1", Seq: seq, Replay: true}) {
+			t.Errorf("seq %d with current journal reported as missed", seq)
+		}
+	}
+	if !pos.After(JournalEvent{Journal: "j1", Seq: 3}) {
+		t.Error("seq 3 should be after position")
+	}
+	if !pos.After(JournalEvent{Journal: "j2", Seq: 1}) {
+		t.Error("event from a rebuilt journal should be after position")
+	}
+}
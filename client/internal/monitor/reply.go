@@ -0,0 +1,100 @@
+package monitor
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// 回复会话的操作（经 reply 控制命令由服务端 reply.sh 通过 tmux send-keys 发送到会话所在窗格）
+const (
+	ReplyApprove   = "approve"   // 批准授权请求：Enter，选择默认的「Yes」
+	ReplyDeny      = "deny"      // 拒绝授权请求：Esc
+	ReplyInterrupt = "interrupt" // 中断正在运行的回合：Esc
+	ReplyText      = "text"      // 输入一行文字并提交
+)
+
+// MaxReplyLength 文字回复的最大长度（字符数）
+const MaxReplyLength = 500
+
+// ReplyRequest 发送到会话 tmux 窗格的回复，Pane 为确认时展示给用户的窗格，
+// 服务端会与 Hook 记录的窗格再次比对
+type ReplyRequest struct {
+	SessionId string
+	Pane      string
+	Action    string
+	Text      string
+}
+
+// ReplyActions 返回会话当前状态下可用的回复操作；会话不在 tmux 中时为空
+func (s ProjectStatus) ReplyActions() []string {
+	if s.TmuxPane == "" {
+		return nil
+	}
+	switch s.Status {
+	case StatusPermission:
+		return []string{ReplyApprove, ReplyDeny}
+	case StatusIdle:
+		return []string{ReplyText}
+	case StatusWorking, StatusCompacting:
+		return []string{ReplyInterrupt}
+	default:
+		return nil
+	}
+}
+
+// NewReply 校验操作与文字并创建回复请求
+func NewReply(s ProjectStatus, action, text string) (ReplyRequest, error) {
+	allowed := false
+	for _, a := range s.ReplyActions() {
+		allowed = allowed || a == action
+	}
+	if !allowed {
+		if s.TmuxPane == "" {
+			return ReplyRequest{}, fmt.Errorf("会话未运行在 tmux 中，无法回复")
+		}
+		return ReplyRequest{}, fmt.Errorf("会话当前状态 %s 不支持操作 %s", s.Status, action)
+	}
+
+	if action == ReplyText {
+		text = strings.TrimSpace(text)
+		switch {
+		case text == "":
+			return ReplyRequest{}, fmt.Errorf("回复内容为空")
+		case strings.ContainsAny(text, "\r\n"):
+			return ReplyRequest{}, fmt.Errorf("回复内容只能是一行")
+		case len([]rune(text)) > MaxReplyLength:
+			return ReplyRequest{}, fmt.Errorf("回复内容超过 %d 个字符", MaxReplyLength)
+		}
+	} else {
+		text = ""
+	}
+
+	return ReplyRequest{SessionId: s.SessionId, Pane: s.TmuxPane, Action: action, Text: text}, nil
+}
+
+// Args 返回 reply 控制命令的参数。控制命令的参数不能包含空白，文字以 base64 编码，
+// 没有文字时省略
+func (r ReplyRequest) Args() []string {
+	args := []string{r.SessionId, r.Pane, r.Action}
+	if r.Text != "" {
+		args = append(args, base64.StdEncoding.EncodeToString([]byte(r.Text)))
+	}
+	return args
+}
+
+// ReplyLabel 返回回复操作的显示名
+func ReplyLabel(action string) string {
+	switch action {
+	case ReplyApprove:
+		return "批准"
+	case ReplyDeny:
+		return "拒绝"
+	case ReplyInterrupt:
+		return "中断"
+	case ReplyText:
+		return "回复"
+	default:
+		return action
+	}
+}
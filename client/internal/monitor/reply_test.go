@@ -0,0 +1,38 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewReply(t *testing.T) {
+	waiting := ProjectStatus{SessionId: "s1", Status: StatusPermission, TmuxPane: "%3"}
+
+	r, err := NewReply(waiting, ReplyApprove, "ignored")
+	if err != nil || r.Pane != "%3" || r.Text != "" {
+		t.Fatalf("approve: got %+v, %v", r, err)
+	}
+	if _, err := NewReply(waiting, ReplyText, "yes"); err == nil {
+		t.Error("text while waiting for permission: want error")
+	}
+
+	idle := ProjectStatus{SessionId: "s1", Status: StatusIdle, TmuxPane: "%3"}
+	if r, err := NewReply(idle, ReplyText, "  run the tests  "); err != nil || r.Text != "run the tests" {
+		t.Errorf("text: got %+v, %v", r, err)
+	}
+	if _, err := NewReply(idle, ReplyText, "a\nb"); err == nil {
+		t.Error("multi-line text: want error")
+	}
+
+	// 控制命令的参数不能包含空白：文字以 base64 编码，没有文字时省略
+	if r, _ := NewReply(idle, ReplyText, "run the tests"); strings.Join(r.Args(), " ") != "s1 %3 text cnVuIHRoZSB0ZXN0cw==" {
+		t.Errorf("text Args() = %q", r.Args())
+	}
+	if r, _ := NewReply(waiting, ReplyApprove, ""); len(r.Args()) != 3 {
+		t.Errorf("approve Args() = %q, want 3 args", r.Args())
+	}
+
+	if _, err := NewReply(ProjectStatus{Status: StatusWorking}, ReplyInterrupt, ""); err == nil {
+		t.Error("no tmux pane: want error")
+	}
+}
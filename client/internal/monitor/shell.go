@@ -0,0 +1,20 @@
+package monitor
+
+import "strings"
+
+// ShellQuote 将参数包裹为单引号字符串，供远程 shell 命令安全拼接
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// BashScriptCommand 构造通过 stdin 执行脚本的 bash 命令行
+func BashScriptCommand(args ...string) string {
+	if len(args) == 0 {
+		return "bash -s"
+	}
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = ShellQuote(a)
+	}
+	return "bash -s -- " + strings.Join(quoted, " ")
+}
@@ -0,0 +1,15 @@
+package monitor
+
+import "testing"
+
+func TestShellQuote(t *testing.T) {
+	if got := ShellQuote("it's"); got != `'it'\''s'` {
+		t.Errorf("ShellQuote = %q", got)
+	}
+	if got := BashScriptCommand(); got != "bash -s" {
+		t.Errorf("BashScriptCommand() = %q", got)
+	}
+	if got := BashScriptCommand("--scope", "a b"); got != "bash -s -- '--scope' 'a b'" {
+		t.Errorf("BashScriptCommand = %q", got)
+	}
+}
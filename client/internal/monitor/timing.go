@@ -0,0 +1,70 @@
+package monitor
+
+import "fmt"
+
+// StateSeconds 返回会话在当前状态停留的秒数；旧版本 Hook 未记录 state_since 时返回 -1
+func (s ProjectStatus) StateSeconds(now int64) int64 {
+	if s.StateSince <= 0 {
+		return -1
+	}
+	if d := now - s.StateSince; d > 0 {
+		return d
+	}
+	return 0
+}
+
+// TotalBusySeconds 返回累计运行秒数，包含正在进行的 working 段
+func (s ProjectStatus) TotalBusySeconds(now int64) int64 {
+	busy := s.BusySeconds
+	if s.Status == StatusWorking && s.StateSince > 0 && now > s.StateSince {
+		busy += now - s.StateSince
+	}
+	return busy
+}
+
+// StateLabel 返回当前状态及停留时长，如 "等待 12m"、"运行 3m"、"自动压缩 20s"；无计时信息时返回空串
+func (s ProjectStatus) StateLabel(now int64) string {
+	secs := s.StateSeconds(now)
+	if secs < 0 {
+		return ""
+	}
+
+	var name string
+	switch s.Status {
+	case StatusWorking:
+		name = "运行"
+	case StatusPermission:
+		name = "待授权"
+	case StatusCompacting:
+		name = "压缩"
+		switch s.CompactTrigger {
+		case "auto":
+			name = "自动压缩"
+		case "manual":
+			name = "手动压缩"
+		}
+	default:
+		name = "等待"
+	}
+	return name + " " + FormatDuration(secs)
+}
+
+// FormatDuration 将秒数格式化为紧凑形式：45s、12m、3h5m、2d4h
+func FormatDuration(secs int64) string {
+	switch {
+	case secs < 60:
+		return fmt.Sprintf("%ds", secs)
+	case secs < 3600:
+		return fmt.Sprintf("%dm", secs/60)
+	case secs < 86400:
+		if m := secs % 3600 / 60; m > 0 {
+			return fmt.Sprintf("%dh%dm", secs/3600, m)
+		}
+		return fmt.Sprintf("%dh", secs/3600)
+	default:
+		if h := secs % 86400 / 3600; h > 0 {
+			return fmt.Sprintf("%dd%dh", secs/86400, h)
+		}
+		return fmt.Sprintf("%dd", secs/86400)
+	}
+}
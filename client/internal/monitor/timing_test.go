@@ -0,0 +1,54 @@
+package monitor
+
+import "testing"
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		secs int64
+		want string
+	}{
+		{0, "0s"},
+		{45, "45s"},
+		{720, "12m"},
+		{3600, "1h"},
+		{3*3600 + 5*60, "3h5m"},
+		{2*86400 + 4*3600, "2d4h"},
+		{86400, "1d"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatDuration(tt.secs); got != tt.want {
+			t.Errorf("FormatDuration(%d) = %q, want %q", tt.secs, got, tt.want)
+		}
+	}
+}
+
+func TestStateTiming(t *testing.T) {
+	now := int64(10000)
+
+	working := ProjectStatus{Status: StatusWorking, StateSince: now - 180, BusySeconds: 60}
+	if got := working.StateLabel(now); got != "运行 3m" {
+		t.Errorf("working label = %q", got)
+	}
+	if got := working.TotalBusySeconds(now); got != 240 {
+		t.Errorf("working busy = %d, want 240", got)
+	}
+
+	idle := ProjectStatus{Status: StatusIdle, StateSince: now - 720, BusySeconds: 60}
+	if got := idle.StateLabel(now); got != "等待 12m" {
+		t.Errorf("idle label = %q", got)
+	}
+	if got := idle.TotalBusySeconds(now); got != 60 {
+		t.Errorf("idle busy = %d, want 60", got)
+	}
+
+	compacting := ProjectStatus{Status: StatusCompacting, CompactTrigger: "auto", StateSince: now - 20}
+	if got := compacting.StateLabel(now); got != "自动压缩 20s" {
+		t.Errorf("compacting label = %q", got)
+	}
+
+	legacy := ProjectStatus{Status: StatusIdle}
+	if got := legacy.StateLabel(now); got != "" {
+		t.Errorf("legacy label = %q, want empty", got)
+	}
+}
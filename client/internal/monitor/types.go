@@ -1,10 +1,33 @@
 package monitor
 
+import "time"
+
 // 消息类型常量
 const (
 	MsgTypeStatus  = "status"
 	MsgTypeError   = "error"
 	MsgTypeVersion = "version"
+	// MsgTypeUsageBlock 当前用量窗口，服务端在窗口用量变化或窗口结束时发送
+	MsgTypeUsageBlock = "usage_block"
+	// MsgTypeResponse 控制命令的回复，Id 与客户端发送命令时的 ID 对应（见 Control）
+	MsgTypeResponse = "response"
+	// MsgTypeEvent 服务端事件日志中的一次会话状态变化，实时发送或由 replay 命令补发
+	MsgTypeEvent = "event"
+)
+
+// 会话状态常量（与 hooks.json 中传给 status-hook.sh 的参数一致）
+const (
+	StatusWorking    = "working"    // 正在运行
+	StatusIdle       = "idle"       // 等待输入
+	StatusPermission = "permission" // 等待用户授权工具调用
+	StatusCompacting = "compacting" // 正在压缩上下文（临时状态）
+	StatusStopped    = "stopped"    // 会话已结束
+)
+
+// 会话状态来源
+const (
+	SourceHook       = ""           // status-hook.sh 写入的状态文件（默认）
+	SourceTranscript = "transcript" // 无 Hook 时由 monitor.sh 从会话记录推断，无计时与进程信息
 )
 
 // ProjectStatus 单个项目的状态
@@ -14,14 +37,83 @@ type ProjectStatus struct {
 	SessionId   string `json:"session_id"`
 	Status      string `json:"status"`
 	UpdatedAt   int64  `json:"updated_at"`
+	Source      string `json:"source,omitempty"`       // 状态来源，见 Source* 常量
+	StartedAt   int64  `json:"started_at,omitempty"`   // 会话首次出现的时间
+	StateSince  int64  `json:"state_since,omitempty"`  // 进入当前状态的时间
+	BusySeconds int64  `json:"busy_seconds,omitempty"` // 此前各段 working 累计秒数（不含当前这一段）
+	ToolName    string `json:"tool_name,omitempty"`    // status=permission 时请求授权的工具
+	ToolSummary string `json:"tool_summary,omitempty"` // 工具参数摘要（已截断，不含文件内容）
+
+	// Git 上下文：GitRoot 为仓库目录（同一仓库的多个 worktree 相同），GitWorktree 为当前
+	// worktree 的顶层目录，GitBranch 分离 HEAD 时为短提交哈希；不在 Git 仓库中时均为空。
+	// 无 Hook 的会话只有会话记录中的 GitBranch
+	GitRoot     string `json:"git_root,omitempty"`
+	GitWorktree string `json:"git_worktree,omitempty"`
+	GitBranch   string `json:"git_branch,omitempty"`
+	GitDirty    bool   `json:"git_dirty,omitempty"`
+
+	// 最近完成的工具调用（PostToolUse），参数摘要为相对项目目录的文件路径或命令首行；
+	// ToolCount 为本轮（自上次提交提示词起）的工具调用次数
+	LastTool        string `json:"last_tool,omitempty"`
+	LastToolSummary string `json:"last_tool_summary,omitempty"`
+	ToolCount       int    `json:"tool_count,omitempty"`
+
+	// 上下文压缩：CompactTrigger 为当前压缩的触发方式（manual|auto，仅 compacting 时存在），
+	// CompactCount 为会话累计压缩次数，频繁压缩时应考虑重开会话
+	CompactTrigger string `json:"compact_trigger,omitempty"`
+	CompactCount   int    `json:"compact_count,omitempty"`
+
+	// Claude Code 进程 PID 与启动时间（/proc/<pid>/stat 的 starttime），
+	// 服务端据此检测进程存活并把已退出的会话标记为 stopped
+	Pid      int   `json:"pid,omitempty"`
+	PidStart int64 `json:"pid_start,omitempty"`
+
+	// 会话所在的 tmux 窗格 / screen 窗口与终端，用于一键连接（见 AttachCommand）
+	TmuxSocket    string `json:"tmux_socket,omitempty"`
+	TmuxPane      string `json:"tmux_pane,omitempty"`
+	ScreenSession string `json:"screen_session,omitempty"`
+	ScreenWindow  string `json:"screen_window,omitempty"`
+	Tty           string `json:"tty,omitempty"`
+
+	// 按模型累计的 Token 用量（可选，需要服务端有 jq 且能找到会话记录）；
+	// EstimatedCost 由客户端按配置的单价计算，服务端不发送
+	Usage         map[string]ModelUsage `json:"usage,omitempty"`
+	EstimatedCost float64               `json:"estimated_cost,omitempty"`
+
+	// 运行中的子代理（Task 工具），会话本身等待输入时也可能仍在后台运行
+	Subagents []Subagent `json:"subagents,omitempty"`
+
+	// 最近一次 Notification Hook 的消息与类型（如 permission_prompt、idle_prompt），
+	// 会话状态再次变化时清除
+	NotificationMessage string `json:"notification_message,omitempty"`
+	NotificationType    string `json:"notification_type,omitempty"`
+}
+
+// Subagent 运行中的子代理
+type Subagent struct {
+	Id          string `json:"id"`          // 启动子代理的 Task 工具调用 ID
+	Description string `json:"description"` // 任务描述（已截断）
+	StartedAt   int64  `json:"started_at"`
 }
 
 // StatusMessage 状态消息
 type StatusMessage struct {
-	Type    string          `json:"type"`              // "status" | "error" | "version"
+	Type    string          `json:"type"`              // 见 MsgType* 常量
 	Data    []ProjectStatus `json:"data,omitempty"`    // type=status 时使用
-	Message string          `json:"message,omitempty"` // type=error 时使用
+	Message string          `json:"message,omitempty"` // type=error / response 时使用
 	Version string          `json:"version,omitempty"` // type=version 时使用
+	Block   *UsageBlock     `json:"block,omitempty"`   // type=usage_block 时使用，无活动窗口时为 nil
+	Now     int64           `json:"now,omitempty"`     // type=status / response 时使用：服务端当前 Unix 时间，用于估计时钟偏差
+
+	// type=response 时使用：对应命令的 ID 与名称，以及是否执行成功
+	Id      int64  `json:"id,omitempty"`
+	Command string `json:"command,omitempty"`
+	Ok      bool   `json:"ok,omitempty"`
+
+	// type=event 时使用：事件、所属事件日志的 ID，以及是否为 replay 补发的历史事件
+	Event   *JournalEvent `json:"event,omitempty"`
+	Journal string        `json:"journal,omitempty"`
+	Replay  bool          `json:"replay,omitempty"`
 }
 
 // Client 监控客户端接口
@@ -30,6 +122,14 @@ type Client interface {
 	Start() error
 	Close()
 	StatusChan() <-chan []ProjectStatus
+	UsageBlockChan() <-chan *UsageBlock
+	// EventChan 返回事件日志的事件（Journal、Replay 已按消息填写）
+	EventChan() <-chan JournalEvent
+	// Request 经监控会话的 stdin 发送控制命令（见 Cmd* 常量）并等待服务端回复，
+	// 返回回复的消息；服务端执行失败、超时或连接已关闭时返回错误
+	Request(command string, args ...string) (string, error)
+	// ClockSkew 返回平滑后的服务端时钟偏差（服务端 - 客户端），与服务端时间戳比较前应加到本地时间上
+	ClockSkew() time.Duration
 	ErrorChan() <-chan error
 	Done() <-chan struct{}
 }
@@ -43,4 +143,7 @@ type Installer interface {
 	// Uninstall 执行服务端卸载。purge=true 时额外清理 settings.json 备份
 	// 以及我们安装时创建的空 settings.json（彻底不留痕迹）。
 	Uninstall(purge bool) error
+	// RunScript 在服务端以 bash 执行脚本（脚本经 stdin 传入，args 作为位置参数），
+	// 返回合并后的 stdout/stderr。用于备份管理等一次性运维操作。
+	RunScript(script string, args ...string) (string, error)
 }
@@ -0,0 +1,78 @@
+package monitor
+
+import "fmt"
+
+// ModelUsage 单个模型的累计 Token 用量（monitor.sh 从会话记录中 assistant 消息的 usage 统计）
+type ModelUsage struct {
+	InputTokens         int64 `json:"input_tokens"`
+	OutputTokens        int64 `json:"output_tokens"`
+	CacheCreationTokens int64 `json:"cache_creation_input_tokens"`
+	CacheReadTokens     int64 `json:"cache_read_input_tokens"`
+}
+
+// Total 返回全部 Token 数（含缓存写入与读取）
+func (u ModelUsage) Total() int64 {
+	return u.InputTokens + u.OutputTokens + u.CacheCreationTokens + u.CacheReadTokens
+}
+
+// TotalTokens 返回会话所有模型的 Token 总数
+func (s ProjectStatus) TotalTokens() int64 {
+	var total int64
+	for _, u := range s.Usage {
+		total += u.Total()
+	}
+	return total
+}
+
+// FormatTokens 将 Token 数格式化为紧凑形式：950、12.3k、1.2M
+func FormatTokens(n int64) string {
+	switch {
+	case n < 1000:
+		return fmt.Sprintf("%d", n)
+	case n < 1000000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	default:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	}
+}
+
+// FormatCost 格式化预估费用（美元）
+func FormatCost(usd float64) string {
+	if usd < 0.01 {
+		return "<$0.01"
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
+
+// UsageBlock 订阅套餐的用量窗口：从第一条用量记录所在的整点开始，持续 5 小时，
+// 由服务端汇总所有会话（含子代理）的会话记录计算
+type UsageBlock struct {
+	Start  int64 `json:"start"`  // 窗口开始时间
+	End    int64 `json:"end"`    // 窗口重置时间
+	Tokens int64 `json:"tokens"` // 窗口内已用 Token 数（含缓存写入与读取）
+}
+
+// SecondsToReset 返回距离窗口重置的秒数，已结束时返回 0
+func (b UsageBlock) SecondsToReset(now int64) int64 {
+	if now >= b.End {
+		return 0
+	}
+	return b.End - now
+}
+
+// Percent 返回已用 Token 占 limit 的百分比，limit 未设置时返回 -1
+func (b UsageBlock) Percent(limit int64) int {
+	if limit <= 0 {
+		return -1
+	}
+	return int(b.Tokens * 100 / limit)
+}
+
+// Label 返回用量窗口的单行描述，如 "用量窗口 1.2M tok (45%) · 2h13m 后重置"
+func (b UsageBlock) Label(now, limit int64) string {
+	label := "用量窗口 " + FormatTokens(b.Tokens) + " tok"
+	if p := b.Percent(limit); p >= 0 {
+		label += fmt.Sprintf(" (%d%%)", p)
+	}
+	return label + " · " + FormatDuration(b.SecondsToReset(now)) + " 后重置"
+}
@@ -0,0 +1,35 @@
+package monitor
+
+import "testing"
+
+func TestFormatTokens(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{950, "950"},
+		{12345, "12.3k"},
+		{1250000, "1.2M"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatTokens(tt.n); got != tt.want {
+			t.Errorf("FormatTokens(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestUsageBlockLabel(t *testing.T) {
+	b := UsageBlock{Start: 0, End: 18000, Tokens: 450000}
+	now := int64(18000 - (2*3600 + 13*60))
+
+	if got := b.Label(now, 1000000); got != "用量窗口 450.0k tok (45%) · 2h13m 后重置" {
+		t.Errorf("Label with limit = %q", got)
+	}
+	if got := b.Label(now, 0); got != "用量窗口 450.0k tok · 2h13m 后重置" {
+		t.Errorf("Label without limit = %q", got)
+	}
+	if got := b.SecondsToReset(20000); got != 0 {
+		t.Errorf("SecondsToReset after end = %d, want 0", got)
+	}
+}
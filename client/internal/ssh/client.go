@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
+	"strings"
 	"time"
 
 	"claude-status/internal/config"
@@ -28,7 +30,12 @@ type Client struct {
 	config    *config.Config
 	client    *ssh.Client
 	session   *ssh.Session
+	conn      net.Conn         // 直连服务端守护进程的 socket，为空时使用 session 上的 monitor.sh
+	control   *monitor.Control // 经 monitor.sh 的 stdin 发送控制命令
 	statusCh  chan []monitor.ProjectStatus
+	blockCh   chan *monitor.UsageBlock
+	eventCh   chan monitor.JournalEvent
+	clock     monitor.ClockSkew // 由 status / response 消息携带的服务端时间估计
 	errorCh   chan error
 	done      chan struct{}
 	versionOK chan bool // 版本检查结果
@@ -39,6 +46,8 @@ func NewClient(cfg *config.Config) *Client {
 	return &Client{
 		config:    cfg,
 		statusCh:  make(chan []monitor.ProjectStatus, 10),
+		blockCh:   make(chan *monitor.UsageBlock, 1),
+		eventCh:   make(chan monitor.JournalEvent, monitor.EventBuffer),
 		errorCh:   make(chan error, 1),
 		done:      make(chan struct{}),
 		versionOK: make(chan bool, 1),
@@ -47,8 +56,8 @@ func NewClient(cfg *config.Config) *Client {
 
 // Connect 连接到服务器
 func (c *Client) Connect() error {
-	// 读取私钥
-	keyPath := c.config.GetIdentityFile()
+	// 读取私钥（已 provision 时使用受限的监控专用密钥）
+	keyPath := c.config.GetMonitorIdentityFile()
 	key, err := os.ReadFile(keyPath)
 	if err != nil {
 		return fmt.Errorf("读取密钥文件失败 (%s): %w", keyPath, err)
@@ -94,8 +103,21 @@ func (c *Client) Connect() error {
 	return nil
 }
 
-// Start 启动监听
+// daemonTimeout 等待服务端守护进程启动并输出 socket 路径的时长
+const daemonTimeout = 10 * time.Second
+
+// Start 启动监听：优先经 direct-streamlocal 直连服务端守护进程，失败时在会话中运行 monitor.sh
+// （服务端有 socat 时其同样桥接到守护进程）
 func (c *Client) Start() error {
+	// 受限密钥的 forced command 只能运行 monitor.sh，无法启动守护进程
+	if c.config.Server.MonitorIdentityFile == "" {
+		conn, err := c.dialDaemon()
+		if err == nil {
+			return c.startDaemonConn(conn)
+		}
+		logger.Info("直连守护进程失败，改用监控会话: %v", err)
+	}
+
 	// 获取 stdout
 	stdout, err := c.session.StdoutPipe()
 	if err != nil {
@@ -108,6 +130,13 @@ func (c *Client) Start() error {
 		return fmt.Errorf("获取 stderr 失败: %w", err)
 	}
 
+	// 获取 stdin 作为控制通道（受限密钥的 forced command 同样转发 stdin）
+	stdin, err := c.session.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("获取 stdin 失败: %w", err)
+	}
+	c.control = monitor.NewControl(stdin)
+
 	// 启动远程命令
 	monitorCmd := "$HOME/.claude-status/monitor.sh"
 	if err := c.session.Start(monitorCmd); err != nil {
@@ -137,7 +166,71 @@ func (c *Client) Start() error {
 		close(c.done)
 	}()
 
-	// 等待版本检查结果（5秒超时）
+	return c.waitVersion()
+}
+
+// dialDaemon 确保服务端守护进程在运行，并经 direct-streamlocal 连接其 Unix socket
+func (c *Client) dialDaemon() (net.Conn, error) {
+	session, err := c.client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("创建会话失败: %w", err)
+	}
+	defer session.Close()
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("获取 stdout 失败: %w", err)
+	}
+	if err := session.Start("$HOME/.claude-status/monitor.sh --ensure-daemon"); err != nil {
+		return nil, fmt.Errorf("启动远程命令失败: %w", err)
+	}
+
+	// 只读第一行：旧版本的 monitor.sh 不认识 --ensure-daemon，会直接输出 version 消息并持续运行
+	lineCh := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(stdout).ReadString('\n')
+		lineCh <- strings.TrimSpace(line)
+	}()
+	var path string
+	select {
+	case path = <-lineCh:
+	case <-time.After(daemonTimeout):
+		return nil, errors.New("等待守护进程启动超时")
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("服务端不支持守护进程: %q", path)
+	}
+
+	conn, err := c.client.Dial("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("连接守护进程 socket 失败 (%s): %w", path, err)
+	}
+	logger.Info("已连接服务端守护进程: %s", path)
+	return conn, nil
+}
+
+// startDaemonConn 在守护进程的 socket 连接上收发消息，连接关闭即视为会话结束
+func (c *Client) startDaemonConn(conn net.Conn) error {
+	c.conn = conn
+	// Connect 预先创建的会话不再需要
+	c.session.Close()
+	c.session = nil
+	c.control = monitor.NewControl(conn)
+
+	go func() {
+		c.readOutput(conn)
+		select {
+		case c.errorCh <- errors.New("守护进程连接已断开"):
+		default:
+		}
+		close(c.done)
+	}()
+
+	return c.waitVersion()
+}
+
+// waitVersion 等待版本检查结果（5秒超时）
+func (c *Client) waitVersion() error {
 	select {
 	case ok := <-c.versionOK:
 		if !ok {
@@ -174,6 +267,10 @@ func (c *Client) readOutput(r io.Reader) {
 		}
 
 		logger.Info("readOutput: parsed message type=%s", msg.Type)
+		if msg.Now > 0 {
+			c.clock.Observe(msg.Now, time.Now())
+		}
+
 		switch msg.Type {
 		case monitor.MsgTypeVersion:
 			if firstMessage {
@@ -207,10 +304,40 @@ func (c *Client) readOutput(r io.Reader) {
 				c.statusCh <- msg.Data
 			}
 
+		case monitor.MsgTypeUsageBlock:
+			// 只保留最新的用量窗口
+			select {
+			case c.blockCh <- msg.Block:
+			default:
+				select {
+				case <-c.blockCh:
+				default:
+				}
+				c.blockCh <- msg.Block
+			}
+
+		case monitor.MsgTypeEvent:
+			// 事件须逐个按序送达（先于随后的 replay 回复），channel 已满时只能丢弃
+			if msg.Event == nil {
+				continue
+			}
+			e := *msg.Event
+			e.Journal, e.Replay = msg.Journal, msg.Replay
+			select {
+			case c.eventCh <- e:
+			default:
+				logger.Error("readOutput: event channel full, dropping event seq=%d", e.Seq)
+			}
+
+		case monitor.MsgTypeResponse:
+			logger.Debug("readOutput: response id=%d command=%s ok=%v", msg.Id, msg.Command, msg.Ok)
+			c.control.Deliver(msg)
+
 		case monitor.MsgTypeError:
 			logger.Error("readOutput: remote error: %s", msg.Message)
 		}
 	}
+	c.control.Close()
 
 	if err := scanner.Err(); err != nil {
 		logger.Error("readOutput: scanner error: %v", err)
@@ -222,11 +349,34 @@ func (c *Client) readOutput(r io.Reader) {
 	logger.Info("readOutput: ended")
 }
 
+// Request 经监控会话的 stdin 发送控制命令并等待回复
+func (c *Client) Request(command string, args ...string) (string, error) {
+	if c.control == nil {
+		return "", monitor.ErrControlClosed
+	}
+	return c.control.Request(monitor.ControlTimeout, command, args...)
+}
+
 // StatusChan 返回状态 channel
 func (c *Client) StatusChan() <-chan []monitor.ProjectStatus {
 	return c.statusCh
 }
 
+// UsageBlockChan 返回用量窗口 channel
+func (c *Client) UsageBlockChan() <-chan *monitor.UsageBlock {
+	return c.blockCh
+}
+
+// EventChan 返回事件日志 channel
+func (c *Client) EventChan() <-chan monitor.JournalEvent {
+	return c.eventCh
+}
+
+// ClockSkew 返回平滑后的服务端时钟偏差
+func (c *Client) ClockSkew() time.Duration {
+	return c.clock.Offset()
+}
+
 // ErrorChan 返回错误 channel
 func (c *Client) ErrorChan() <-chan error {
 	return c.errorCh
@@ -239,6 +389,12 @@ func (c *Client) Done() <-chan struct{} {
 
 // Close 关闭连接
 func (c *Client) Close() {
+	if c.control != nil {
+		c.control.Close()
+	}
+	if c.conn != nil {
+		c.conn.Close()
+	}
 	if c.session != nil {
 		c.session.Close()
 	}
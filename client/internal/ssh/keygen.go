@@ -0,0 +1,71 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// MonitorKeyName 监控专用密钥的文件名（位于客户端数据目录）
+const MonitorKeyName = "monitor_ed25519"
+
+// MonitorKeyComment 监控密钥在 authorized_keys 中的注释，服务端据此识别并替换/移除该行
+const MonitorKeyComment = "claude-status-monitor"
+
+// MonitorKeyOptions 监控密钥在 authorized_keys 中的限制选项：
+// 强制只能运行 monitor.sh，禁止 pty、端口/agent/X11 转发及 ~/.ssh/rc
+const MonitorKeyOptions = `command="~/.claude-status/monitor.sh",no-pty,no-port-forwarding,no-agent-forwarding,no-X11-forwarding,no-user-rc`
+
+// EnsureMonitorKey 在 dir 下生成 ed25519 监控密钥（已存在则直接复用），
+// 返回私钥路径与公钥（authorized_keys 格式，不含选项）。
+func EnsureMonitorKey(dir string) (string, string, error) {
+	keyPath := filepath.Join(dir, MonitorKeyName)
+
+	if data, err := os.ReadFile(keyPath); err == nil {
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			return "", "", fmt.Errorf("解析已有监控密钥失败 (%s): %w", keyPath, err)
+		}
+		return keyPath, authorizedKey(signer.PublicKey()), nil
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("生成密钥失败: %w", err)
+	}
+
+	block, err := ssh.MarshalPrivateKey(priv, MonitorKeyComment)
+	if err != nil {
+		return "", "", fmt.Errorf("序列化私钥失败: %w", err)
+	}
+
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return "", "", fmt.Errorf("生成公钥失败: %w", err)
+	}
+	line := authorizedKey(sshPub)
+
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(block), 0600); err != nil {
+		return "", "", fmt.Errorf("写入私钥失败: %w", err)
+	}
+	if err := os.WriteFile(keyPath+".pub", []byte(line+"\n"), 0644); err != nil {
+		return "", "", fmt.Errorf("写入公钥失败: %w", err)
+	}
+	return keyPath, line, nil
+}
+
+// MonitorAuthorizedKeyLine 返回写入服务端 authorized_keys 的完整行（选项 + 公钥 + 注释）
+func MonitorAuthorizedKeyLine(publicKey string) string {
+	return MonitorKeyOptions + " " + publicKey
+}
+
+// authorizedKey 返回 "<type> <base64> claude-status-monitor" 形式的公钥
+func authorizedKey(pub ssh.PublicKey) string {
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub))) + " " + MonitorKeyComment
+}
@@ -4,6 +4,8 @@ package popup
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"claude-status/internal/monitor"
 
@@ -12,15 +14,51 @@ import (
 
 // ProjectGroup 按项目分组的会话统计
 type ProjectGroup struct {
-	ProjectName string // 项目显示名
-	Running     int    // 正在运行（working）的会话数
-	Total       int    // 总会话数
+	ProjectName string  // 显示名：仓库名与分支（如 "app · feat-a*"），不在 Git 仓库中时为项目名
+	Running     int     // 正在运行（working）的会话数
+	Permission  int     // 等待授权（permission）的会话数
+	Compacting  int     // 正在压缩上下文（compacting）的会话数
+	Tokens      int64   // 所有会话的 Token 合计
+	Cost        float64 // 所有会话的预估费用合计（美元）
+	Total       int     // 总会话数
+	Detail      string  // 等待授权的工具摘要，如 "Bash: rm -rf build"
+	SubText     string  // 第二行：状态时长（如 "等待 12m"）、工具活动、子代理数与最近的通知消息
+	Stale       bool    // 有会话无人处理超过 StaleSeconds，第二行以醒目颜色显示
+
+	// Attach 点击该行时连接的会话（运行在 tmux/screen 中，优先取代表会话），为 nil 时不可连接
+	Attach *monitor.ProjectStatus
+	// Session 代表会话（优先待授权者），右键菜单的操作对象
+	Session *monitor.ProjectStatus
+}
+
+// StaleSeconds 会话等待输入/授权超过该时长即视为无人处理
+const StaleSeconds = 3600
+
+// statusRank 选取分组代表会话时的优先级：待授权 > 压缩中 > 等待输入 > 运行中
+func statusRank(status string) int {
+	switch status {
+	case monitor.StatusPermission:
+		return 3
+	case monitor.StatusCompacting:
+		return 2
+	case monitor.StatusWorking:
+		return 0
+	default:
+		return 1
+	}
 }
 
 // SessionList 会话列表（极简风格）
 type SessionList struct {
 	widget *walk.CustomWidget
 	groups []*ProjectGroup
+	header string // 列表顶部的用量窗口与延迟行，为空时不显示
+
+	onAttach func(monitor.ProjectStatus) // 点击可连接的会话行时调用
+
+	// 右键菜单：按下右键时由 menuActions 按该行的代表会话重建菜单项
+	menu        *walk.Menu
+	menuActions func(monitor.ProjectStatus) []*walk.Action
 }
 
 // NewSessionList 创建会话列表
@@ -40,6 +78,25 @@ func NewSessionList(parent walk.Container) (*SessionList, error) {
 		walk.Size{Width: Window.Width, Height: Window.MaxHeight},
 	)
 
+	sl.menu, err = walk.NewMenu()
+	if err != nil {
+		return nil, err
+	}
+	sl.widget.SetContextMenu(sl.menu)
+
+	sl.widget.MouseDown().Attach(func(x, y int, button walk.MouseButton) {
+		g := sl.groupAt(y)
+		switch button {
+		case walk.LeftButton:
+			if g != nil && g.Attach != nil && sl.onAttach != nil {
+				sl.onAttach(*g.Attach)
+			}
+		case walk.RightButton:
+			// WM_CONTEXTMENU 在右键抬起后才到达，此时菜单已是该行的操作
+			sl.rebuildMenu(g)
+		}
+	})
+
 	return sl, nil
 }
 
@@ -55,26 +112,79 @@ func (sl *SessionList) paint(canvas *walk.Canvas, updateBounds walk.Rectangle) e
 	defer bgBrush.Dispose()
 	canvas.FillRectanglePixels(bgBrush, bounds)
 
-	// 绘制分组列表项
-	for i, group := range sl.groups {
-		sl.paintGroup(canvas, group, i)
+	// 顶部用量窗口与延迟行
+	y := Window.Padding
+	if sl.header != "" {
+		sl.paintHeader(canvas, y)
+		y += Item.Height
+	}
+
+	// 绘制分组列表项（带通知消息的项占两行）
+	for _, group := range sl.groups {
+		sl.paintGroup(canvas, group, y)
+		y += groupHeight(group)
 	}
 
 	// 空状态
 	if len(sl.groups) == 0 {
+		bounds.Y = y - Window.Padding
+		bounds.Height -= bounds.Y
 		sl.paintEmpty(canvas, bounds)
 	}
 
 	return nil
 }
 
-// paintGroup 绘制单个分组项
-func (sl *SessionList) paintGroup(canvas *walk.Canvas, group *ProjectGroup, index int) {
-	y := Window.Padding + index*Item.Height
+// rebuildMenu 按分组项的代表会话重建右键菜单，没有可用操作时显示一条禁用的提示
+func (sl *SessionList) rebuildMenu(g *ProjectGroup) {
+	actions := sl.menu.Actions()
+	actions.Clear()
+	if g != nil && g.Session != nil && sl.menuActions != nil {
+		for _, a := range sl.menuActions(*g.Session) {
+			actions.Add(a)
+		}
+	}
+	if actions.Len() == 0 {
+		a := walk.NewAction()
+		a.SetText("无可用操作")
+		a.SetEnabled(false)
+		actions.Add(a)
+	}
+}
 
-	// 状态颜色：有 running 会话则绿色，否则黄色
+// groupAt 返回纵坐标 y 处的分组项，与 paint 的布局一致
+func (sl *SessionList) groupAt(y int) *ProjectGroup {
+	top := Window.Padding
+	if sl.header != "" {
+		top += Item.Height
+	}
+	for _, group := range sl.groups {
+		h := groupHeight(group)
+		if y >= top && y < top+h {
+			return group
+		}
+		top += h
+	}
+	return nil
+}
+
+// groupHeight 返回分组项的高度
+func groupHeight(group *ProjectGroup) int {
+	if group.SubText != "" {
+		return Item.Height + Item.SubHeight
+	}
+	return Item.Height
+}
+
+// paintGroup 绘制单个分组项，y 为该项顶部坐标
+func (sl *SessionList) paintGroup(canvas *walk.Canvas, group *ProjectGroup, y int) {
+	// 状态颜色：有等待授权的会话则红色，其次压缩中紫色，有 running 会话则绿色，否则黄色
 	var dotColor walk.Color
-	if group.Running > 0 {
+	if group.Permission > 0 {
+		dotColor = Colors.Permission
+	} else if group.Compacting > 0 {
+		dotColor = Colors.Compacting
+	} else if group.Running > 0 {
 		dotColor = Colors.Working
 	} else {
 		dotColor = Colors.Idle
@@ -100,9 +210,13 @@ func (sl *SessionList) paintGroup(canvas *walk.Canvas, group *ProjectGroup, inde
 	}
 	defer font.Dispose()
 
-	// 右侧计数文本 "a/b"
+	// 右侧计数文本 "a/b"，可连接的会话前加 "↗" 提示可点击
 	countText := fmt.Sprintf("%d/%d", group.Running, group.Total)
 	countWidth := Scale(36)
+	if group.Attach != nil {
+		countText = "↗ " + countText
+		countWidth = Scale(52)
+	}
 	countRect := walk.Rectangle{
 		X:      Window.Width - Window.Padding - countWidth,
 		Y:      y,
@@ -120,7 +234,52 @@ func (sl *SessionList) paintGroup(canvas *walk.Canvas, group *ProjectGroup, inde
 		Width:  countRect.X - textX - Item.DotMargin,
 		Height: Item.Height,
 	}
-	canvas.DrawTextPixels(group.ProjectName, font, Colors.TextPrimary, textRect,
+	name := group.ProjectName
+	if group.Detail != "" {
+		name += " · " + group.Detail
+	}
+	canvas.DrawTextPixels(name, font, Colors.TextPrimary, textRect,
+		walk.TextLeft|walk.TextVCenter|walk.TextSingleLine|walk.TextEndEllipsis)
+
+	// 第二行：状态时长与通知消息
+	if group.SubText != "" {
+		subFont, err := walk.NewFont(Fonts.Primary, Fonts.SubSize, 0)
+		if err != nil {
+			return
+		}
+		defer subFont.Dispose()
+
+		subRect := walk.Rectangle{
+			X:      textX,
+			Y:      y + Item.Height - Item.SubHeight/2,
+			Width:  Window.Width - Window.Padding - textX,
+			Height: Item.SubHeight,
+		}
+		subColor := Colors.TextMuted
+		if group.Stale {
+			subColor = Colors.Idle
+		}
+		canvas.DrawTextPixels(group.SubText, subFont, subColor, subRect,
+			walk.TextLeft|walk.TextTop|walk.TextSingleLine|walk.TextEndEllipsis)
+	}
+}
+
+// paintHeader 绘制顶部用量窗口与延迟行，y 为该行顶部坐标
+func (sl *SessionList) paintHeader(canvas *walk.Canvas, y int) {
+	font, err := walk.NewFont(Fonts.Primary, Fonts.SubSize, 0)
+	if err != nil {
+		return
+	}
+	defer font.Dispose()
+
+	x := Window.Padding + Item.DotMargin
+	rect := walk.Rectangle{
+		X:      x,
+		Y:      y,
+		Width:  Window.Width - Window.Padding - x,
+		Height: Item.Height,
+	}
+	canvas.DrawTextPixels(sl.header, font, Colors.TextMuted, rect,
 		walk.TextLeft|walk.TextVCenter|walk.TextSingleLine|walk.TextEndEllipsis)
 }
 
@@ -136,46 +295,145 @@ func (sl *SessionList) paintEmpty(canvas *walk.Canvas, bounds walk.Rectangle) {
 		walk.TextCenter|walk.TextVCenter|walk.TextSingleLine)
 }
 
-// Update 更新会话列表
-func (sl *SessionList) Update(statuses []monitor.ProjectStatus) {
+// Update 更新会话列表，now 为服务端当前时间（用于计算状态时长）
+func (sl *SessionList) Update(statuses []monitor.ProjectStatus, now int64) {
 	// 过滤 stopped 状态
 	filtered := make([]monitor.ProjectStatus, 0, len(statuses))
 	for _, s := range statuses {
-		if s.Status != "stopped" {
+		if s.Status != monitor.StatusStopped {
 			filtered = append(filtered, s)
 		}
 	}
 
-	// 按项目目录分组统计
+	// 按 worktree（不在 Git 仓库中时按项目目录）分组统计
 	type groupStats struct {
-		name    string
-		running int
-		total   int
+		name       string
+		repo       string
+		updatedAt  int64 // 显示名取自最近更新的会话（分支与修改标记以最新为准）
+		running    int
+		permission int
+		compacting int
+		total      int
+		detail     string
+		message    string
+		subagents  int
+		tokens     int64
+		cost       float64
+		rep        *monitor.ProjectStatus // 代表会话，用于显示状态时长
+		attach     *monitor.ProjectStatus // 第一个可连接的会话
 	}
 	groupMap := make(map[string]*groupStats)
 	var groupOrder []string
 
-	for _, s := range filtered {
-		g, exists := groupMap[s.Project]
+	for i := range filtered {
+		s := filtered[i]
+		key := s.WorktreeKey()
+		g, exists := groupMap[key]
 		if !exists {
-			g = &groupStats{name: s.ProjectName}
-			groupMap[s.Project] = g
-			groupOrder = append(groupOrder, s.Project)
+			g = &groupStats{repo: s.RepoKey()}
+			groupMap[key] = g
+			groupOrder = append(groupOrder, key)
+		}
+		if g.name == "" || s.UpdatedAt > g.updatedAt {
+			g.name = s.DisplayName()
+			g.updatedAt = s.UpdatedAt
 		}
 		g.total++
-		if s.Status == "working" {
+		switch s.Status {
+		case monitor.StatusWorking:
 			g.running++
+		case monitor.StatusPermission:
+			g.permission++
+			if g.detail == "" {
+				g.detail = permissionDetail(s)
+			}
+		case monitor.StatusCompacting:
+			g.compacting++
+		}
+		if g.message == "" {
+			g.message = s.NotificationMessage
+		}
+		g.subagents += len(s.Subagents)
+		g.tokens += s.TotalTokens()
+		g.cost += s.EstimatedCost
+		// 代表会话：优先级高者优先，同优先级取停留最久者
+		if g.rep == nil || statusRank(s.Status) > statusRank(g.rep.Status) ||
+			(statusRank(s.Status) == statusRank(g.rep.Status) && s.StateSeconds(now) > g.rep.StateSeconds(now)) {
+			g.rep = &filtered[i]
+		}
+		if g.attach == nil && s.AttachCommand() != "" {
+			g.attach = &filtered[i]
 		}
 	}
 
-	// 按出现顺序构建分组列表
+	// 按出现顺序构建分组列表，同一仓库的各 worktree 相邻
+	repoOrder := make(map[string]int)
+	for _, key := range groupOrder {
+		if _, ok := repoOrder[groupMap[key].repo]; !ok {
+			repoOrder[groupMap[key].repo] = len(repoOrder)
+		}
+	}
+	sort.SliceStable(groupOrder, func(i, j int) bool {
+		return repoOrder[groupMap[groupOrder[i]].repo] < repoOrder[groupMap[groupOrder[j]].repo]
+	})
+
 	groups := make([]*ProjectGroup, 0, len(groupOrder))
-	for _, project := range groupOrder {
-		g := groupMap[project]
+	for _, key := range groupOrder {
+		g := groupMap[key]
+
+		var parts []string
+		stale := false
+		if g.rep != nil {
+			if label := g.rep.StateLabel(now); label != "" {
+				parts = append(parts, label)
+			}
+			if activity := g.rep.ActivityLabel(); activity != "" {
+				parts = append(parts, activity)
+				if g.rep.ToolCount > 0 {
+					parts = append(parts, fmt.Sprintf("%d 次调用", g.rep.ToolCount))
+				}
+			}
+			if g.rep.Source == monitor.SourceTranscript {
+				// 无 Hook，状态由会话记录推断
+				parts = append(parts, "无 Hook")
+			}
+			if g.rep.CompactCount > 0 {
+				parts = append(parts, fmt.Sprintf("已压缩 %d 次", g.rep.CompactCount))
+			}
+			stale = g.rep.Status != monitor.StatusWorking && g.rep.Status != monitor.StatusCompacting &&
+				g.rep.StateSeconds(now) >= StaleSeconds
+		}
+		if g.subagents > 0 {
+			// 子代理仍在运行，会话并非无人处理
+			parts = append(parts, fmt.Sprintf("%d 个子代理运行中", g.subagents))
+			stale = false
+		}
+		if g.tokens > 0 {
+			parts = append(parts, monitor.FormatTokens(g.tokens)+" tok "+monitor.FormatCost(g.cost))
+		}
+		if g.message != "" {
+			parts = append(parts, g.message)
+		}
+
+		// 可连接的会话：优先取代表会话
+		attach := g.attach
+		if g.rep != nil && g.rep.AttachCommand() != "" {
+			attach = g.rep
+		}
+
 		groups = append(groups, &ProjectGroup{
 			ProjectName: g.name,
 			Running:     g.running,
+			Permission:  g.permission,
+			Compacting:  g.compacting,
+			Tokens:      g.tokens,
+			Cost:        g.cost,
 			Total:       g.total,
+			Detail:      g.detail,
+			SubText:     strings.Join(parts, " · "),
+			Stale:       stale,
+			Attach:      attach,
+			Session:     g.rep,
 		})
 	}
 
@@ -183,6 +441,24 @@ func (sl *SessionList) Update(statuses []monitor.ProjectStatus) {
 	sl.widget.Invalidate()
 }
 
+// permissionDetail 返回等待授权会话的工具摘要
+func permissionDetail(s monitor.ProjectStatus) string {
+	switch {
+	case s.ToolName != "" && s.ToolSummary != "":
+		return s.ToolName + ": " + s.ToolSummary
+	case s.ToolName != "":
+		return s.ToolName
+	default:
+		return "等待授权"
+	}
+}
+
+// SetHeader 设置顶部用量窗口与延迟行，空串表示不显示
+func (sl *SessionList) SetHeader(text string) {
+	sl.header = text
+	sl.widget.Invalidate()
+}
+
 // GetItemCount 获取分组数量
 func (sl *SessionList) GetItemCount() int {
 	return len(sl.groups)
@@ -190,7 +466,20 @@ func (sl *SessionList) GetItemCount() int {
 
 // GetHeight 获取列表高度
 func (sl *SessionList) GetHeight() int {
-	return CalcWindowHeight(len(sl.groups))
+	subLines := 0
+	for _, g := range sl.groups {
+		if g.SubText != "" {
+			subLines++
+		}
+	}
+	height := CalcWindowHeight(len(sl.groups), subLines)
+	if sl.header != "" {
+		height += Item.Height
+		if height > Window.MaxHeight {
+			height = Window.MaxHeight
+		}
+	}
+	return height
 }
 
 // SetSize 设置控件大小
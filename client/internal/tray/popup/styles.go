@@ -72,16 +72,19 @@ var Window struct {
 
 var baseItem = struct {
 	Height    int
+	SubHeight int
 	DotSize   int
 	DotMargin int
 }{
 	Height:    36,
+	SubHeight: 16,
 	DotSize:   8,
 	DotMargin: 8,
 }
 
 var Item struct {
 	Height    int
+	SubHeight int // 第二行（通知消息）额外占用的高度
 	DotSize   int
 	DotMargin int
 }
@@ -93,9 +96,11 @@ var Item struct {
 var Fonts = struct {
 	Primary string
 	Size    int
+	SubSize int
 }{
 	Primary: "Segoe UI",
 	Size:    11,
+	SubSize: 9,
 }
 
 // ============================================================
@@ -111,8 +116,10 @@ type ColorScheme struct {
 	TextMuted   walk.Color
 
 	// 状态
-	Working walk.Color
-	Idle    walk.Color
+	Working    walk.Color
+	Idle       walk.Color
+	Permission walk.Color // 等待授权，最需要及时处理
+	Compacting walk.Color // 正在压缩上下文
 }
 
 var darkColors = ColorScheme{
@@ -121,6 +128,8 @@ var darkColors = ColorScheme{
 	TextMuted:   walk.RGB(120, 120, 130),
 	Working:     walk.RGB(52, 211, 153),  // #34D399
 	Idle:        walk.RGB(251, 191, 36),  // #FBBF24
+	Permission:  walk.RGB(248, 113, 113), // #F87171
+	Compacting:  walk.RGB(167, 139, 250), // #A78BFA
 }
 
 var lightColors = ColorScheme{
@@ -129,6 +138,8 @@ var lightColors = ColorScheme{
 	TextMuted:   walk.RGB(140, 140, 150),
 	Working:     walk.RGB(34, 197, 94),
 	Idle:        walk.RGB(234, 179, 8),
+	Permission:  walk.RGB(220, 38, 38),
+	Compacting:  walk.RGB(124, 58, 237),
 }
 
 var Colors = darkColors
@@ -154,16 +165,18 @@ func UpdateDPIScaling() {
 	Window.MaxHeight = Scale(baseWindow.MaxHeight)
 
 	Item.Height = Scale(baseItem.Height)
+	Item.SubHeight = Scale(baseItem.SubHeight)
 	Item.DotSize = Scale(baseItem.DotSize)
 	Item.DotMargin = Scale(baseItem.DotMargin)
 }
 
-func CalcWindowHeight(itemCount int) int {
+// CalcWindowHeight 计算窗口高度，subLineCount 为带第二行（通知消息）的列表项数量
+func CalcWindowHeight(itemCount, subLineCount int) int {
 	if itemCount == 0 {
 		return Item.Height + Window.Padding*2
 	}
 
-	contentHeight := itemCount*Item.Height + Window.Padding*2
+	contentHeight := itemCount*Item.Height + subLineCount*Item.SubHeight + Window.Padding*2
 	if contentHeight > Window.MaxHeight {
 		return Window.MaxHeight
 	}
@@ -3,6 +3,8 @@
 package popup
 
 import (
+	"fmt"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -14,14 +16,18 @@ import (
 	"github.com/lxn/win"
 )
 
-
-
 // PopupWindow 悬浮窗口
 type PopupWindow struct {
 	window   *walk.MainWindow
 	list     *SessionList
 	statuses []monitor.ProjectStatus
 
+	// 当前用量窗口与配置的 Token 上限，标签在显示/刷新时按当前时间计算
+	block      *monitor.UsageBlock
+	blockLimit int64
+	latency    time.Duration // 监控连接的往返延迟，0 不显示
+	skew       time.Duration // 服务端时钟相对本机的偏差，时长按服务端时间计算
+
 	mu        sync.Mutex
 	isVisible bool
 
@@ -147,10 +153,13 @@ func (pw *PopupWindow) ShowAt(x, y int) {
 	}
 	pw.isVisible = true
 	statuses := pw.statuses
+	header := pw.headerLabel()
+	skew := pw.skew
 	pw.mu.Unlock()
 
 	// 先更新内容（在显示之前）
-	pw.list.Update(statuses)
+	pw.list.SetHeader(header)
+	pw.list.Update(statuses, monitor.ServerNow(skew))
 
 	// 获取窗口高度（根据项目数量）
 	windowHeight := pw.list.GetHeight()
@@ -252,8 +261,74 @@ func (pw *PopupWindow) UpdateSessions(statuses []monitor.ProjectStatus) {
 
 	// 如果窗口可见，更新显示
 	if pw.isVisible {
-		pw.list.Update(statuses)
+		pw.list.SetHeader(pw.headerLabel())
+		pw.list.Update(statuses, monitor.ServerNow(pw.skew))
+	}
+}
+
+// SetAttachHandler 设置点击可连接的会话行时的回调，回调后隐藏窗口
+func (pw *PopupWindow) SetAttachHandler(fn func(monitor.ProjectStatus)) {
+	pw.list.onAttach = func(s monitor.ProjectStatus) {
+		fn(s)
+		pw.Hide()
+	}
+}
+
+// SetMenuActions 设置会话行右键菜单的操作，按下右键时以该行的代表会话调用
+func (pw *PopupWindow) SetMenuActions(fn func(monitor.ProjectStatus) []*walk.Action) {
+	pw.list.menuActions = fn
+}
+
+// UpdateUsageBlock 更新用量窗口，block 为 nil 表示当前没有活动窗口
+func (pw *PopupWindow) UpdateUsageBlock(block *monitor.UsageBlock, limit int64) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	pw.block = block
+	pw.blockLimit = limit
+
+	if pw.isVisible {
+		pw.list.SetHeader(pw.headerLabel())
+	}
+}
+
+// SetLatency 更新监控连接的往返延迟，0 表示不显示
+func (pw *PopupWindow) SetLatency(rtt time.Duration) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	pw.latency = rtt
+
+	if pw.isVisible {
+		pw.list.SetHeader(pw.headerLabel())
+	}
+}
+
+// SetClockSkew 更新服务端时钟偏差，可见时按校正后的时间重绘
+func (pw *PopupWindow) SetClockSkew(skew time.Duration) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	pw.skew = skew
+
+	if pw.isVisible {
+		pw.list.SetHeader(pw.headerLabel())
+		pw.list.Update(pw.statuses, monitor.ServerNow(skew))
+	}
+}
+
+// headerLabel 返回顶部行的文本：用量窗口（不存在或已重置时省略）与连接延迟，
+// 都没有时为空（调用方需持有 mu）
+func (pw *PopupWindow) headerLabel() string {
+	var parts []string
+	now := monitor.ServerNow(pw.skew)
+	if pw.block != nil && pw.block.SecondsToReset(now) > 0 {
+		parts = append(parts, pw.block.Label(now, pw.blockLimit))
+	}
+	if pw.latency > 0 {
+		parts = append(parts, fmt.Sprintf("延迟 %d ms", pw.latency.Milliseconds()))
 	}
+	return strings.Join(parts, " · ")
 }
 
 // IsVisible 返回窗口是否可见
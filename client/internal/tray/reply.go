@@ -0,0 +1,142 @@
+//go:build windows
+
+package tray
+
+import (
+	"fmt"
+
+	"claude-status/internal/logger"
+	"claude-status/internal/monitor"
+
+	"github.com/lxn/walk"
+)
+
+// sessionActions 返回悬浮窗会话行的右键菜单项（移除会话总是可用）
+func (t *App) sessionActions(s monitor.ProjectStatus) []*walk.Action {
+	var actions []*walk.Action
+	add := func(text string, fn func()) {
+		a := walk.NewAction()
+		a.SetText(text)
+		a.Triggered().Attach(fn)
+		actions = append(actions, a)
+	}
+
+	if s.AttachCommand() != "" {
+		add("连接终端", func() {
+			select {
+			case t.attachCh <- s:
+			default:
+			}
+		})
+	}
+	for _, action := range s.ReplyActions() {
+		action := action
+		text := monitor.ReplyLabel(action)
+		if action == monitor.ReplyText {
+			text += "..."
+		}
+		add(text, func() { t.confirmReply(s, action) })
+	}
+	add("从列表移除", func() { t.confirmRemove(s) })
+	return actions
+}
+
+// confirmRemove 经用户确认后请求服务端删除会话的状态文件
+func (t *App) confirmRemove(s monitor.ProjectStatus) {
+	msg := fmt.Sprintf("从列表移除 %s 的会话？\n\n将删除服务端的状态文件，会话仍在运行时下次触发 Hook 会重新出现。", s.DisplayName())
+	if walk.MsgBox(nil, "移除会话", msg, walk.MsgBoxYesNo|walk.MsgBoxIconQuestion|walk.MsgBoxTopMost) != walk.DlgCmdYes {
+		return
+	}
+
+	select {
+	case t.removeCh <- s:
+	default:
+		logger.Info("confirmRemove: 上一个移除请求尚未处理，忽略")
+	}
+}
+
+// confirmReply 输入回复内容（仅文字回复）并经用户确认后提交到 replyCh
+func (t *App) confirmReply(s monitor.ProjectStatus, action string) {
+	var text string
+	if action == monitor.ReplyText {
+		var ok bool
+		if text, ok = t.promptReplyText(s); !ok {
+			return
+		}
+	}
+
+	req, err := monitor.NewReply(s, action, text)
+	if err != nil {
+		walk.MsgBox(nil, "无法回复会话", err.Error(), walk.MsgBoxOK|walk.MsgBoxIconWarning|walk.MsgBoxTopMost)
+		return
+	}
+
+	msg := fmt.Sprintf("向 %s 的 tmux 窗格 %s 发送「%s」？", s.DisplayName(), req.Pane, monitor.ReplyLabel(action))
+	if req.Text != "" {
+		msg += "\n\n" + req.Text
+	}
+	if walk.MsgBox(nil, "确认回复会话", msg, walk.MsgBoxYesNo|walk.MsgBoxIconQuestion|walk.MsgBoxTopMost) != walk.DlgCmdYes {
+		return
+	}
+
+	select {
+	case t.replyCh <- req:
+	default:
+		logger.Info("confirmReply: 上一条回复尚未发送，忽略")
+	}
+}
+
+// promptReplyText 弹出单行输入框，返回输入内容与是否确认
+func (t *App) promptReplyText(s monitor.ProjectStatus) (string, bool) {
+	dlg, err := walk.NewDialog(t.mainWindow)
+	if err != nil {
+		logger.Error("创建回复对话框失败: %v", err)
+		return "", false
+	}
+	defer dlg.Dispose()
+
+	dlg.SetTitle("回复会话")
+	dlg.SetLayout(walk.NewVBoxLayout())
+	dlg.SetMinMaxSize(walk.Size{Width: 420}, walk.Size{})
+
+	label, err := walk.NewLabel(dlg)
+	if err != nil {
+		return "", false
+	}
+	label.SetText(fmt.Sprintf("回复 %s（发送到 tmux 窗格 %s）：", s.DisplayName(), s.TmuxPane))
+
+	edit, err := walk.NewLineEdit(dlg)
+	if err != nil {
+		return "", false
+	}
+	edit.SetMaxLength(monitor.MaxReplyLength)
+
+	buttons, err := walk.NewComposite(dlg)
+	if err != nil {
+		return "", false
+	}
+	buttons.SetLayout(walk.NewHBoxLayout())
+	walk.NewHSpacer(buttons)
+
+	ok, err := walk.NewPushButton(buttons)
+	if err != nil {
+		return "", false
+	}
+	ok.SetText("发送")
+	ok.Clicked().Attach(dlg.Accept)
+	dlg.SetDefaultButton(ok)
+
+	cancel, err := walk.NewPushButton(buttons)
+	if err != nil {
+		return "", false
+	}
+	cancel.SetText("取消")
+	cancel.Clicked().Attach(dlg.Cancel)
+	dlg.SetCancelButton(cancel)
+
+	edit.SetFocus()
+	if dlg.Run() != walk.DlgCmdOK {
+		return "", false
+	}
+	return edit.Text(), true
+}
@@ -36,13 +36,14 @@ type serverMenuItem struct {
 
 // App 系统托盘应用
 type App struct {
-	mainWindow    *walk.MainWindow
-	notifyIcon    *walk.NotifyIcon
-	popupWindow   *popup.PopupWindow
-	contextMenu   *walk.Menu
-	mStatus       *walk.Action
-	mConnection   *walk.Action
-	connectionMenu *walk.Menu
+	mainWindow      *walk.MainWindow
+	notifyIcon      *walk.NotifyIcon
+	popupWindow     *popup.PopupWindow
+	contextMenu     *walk.Menu
+	mStatus         *walk.Action
+	mRefresh        *walk.Action
+	mConnection     *walk.Action
+	connectionMenu  *walk.Menu
 	serverMenuItems []*serverMenuItem
 
 	statuses        []monitor.ProjectStatus
@@ -50,8 +51,13 @@ type App struct {
 	quitCh          chan struct{}
 	disconnectCh    chan struct{}
 	serverSelectCh  chan config.ServerConfig
+	attachCh        chan monitor.ProjectStatus
+	replyCh         chan monitor.ReplyRequest
+	refreshCh       chan struct{}
+	removeCh        chan monitor.ProjectStatus
 	currentIcon     string
 	connectedServer string
+	skewWarned      bool // tooltip 正在显示时钟偏差提示
 
 	// 主题相关
 	isDarkMode bool
@@ -64,8 +70,8 @@ type App struct {
 	animFrame   int
 
 	// 图标缓存（避免每帧重复创建）
-	iconCache     map[string]*walk.Icon
-	iconCacheMu   sync.Mutex
+	iconCache   map[string]*walk.Icon
+	iconCacheMu sync.Mutex
 
 	// 悬浮检测
 	hoverMu       sync.Mutex
@@ -81,6 +87,10 @@ func NewApp() *App {
 		quitCh:          make(chan struct{}),
 		disconnectCh:    make(chan struct{}, 1),
 		serverSelectCh:  make(chan config.ServerConfig, 1),
+		attachCh:        make(chan monitor.ProjectStatus, 1),
+		replyCh:         make(chan monitor.ReplyRequest, 1),
+		refreshCh:       make(chan struct{}, 1),
+		removeCh:        make(chan monitor.ProjectStatus, 1),
 		currentIcon:     "",
 		serverMenuItems: make([]*serverMenuItem, 0),
 		connectedServer: "",
@@ -117,6 +127,15 @@ func (t *App) Run(onReady func(), onQuit func()) {
 	} else {
 		// 设置初始主题
 		t.popupWindow.SetDarkMode(t.isDarkMode)
+		// 点击会话行：连接到会话所在的 tmux 窗格 / screen 窗口
+		t.popupWindow.SetAttachHandler(func(s monitor.ProjectStatus) {
+			select {
+			case t.attachCh <- s:
+			default:
+			}
+		})
+		// 右键会话行：连接终端、批准/拒绝授权、回复、中断、移除
+		t.popupWindow.SetMenuActions(t.sessionActions)
 	}
 
 	// 初始化托盘
@@ -197,6 +216,18 @@ func (t *App) setupContextMenu() {
 	t.mStatus.SetEnabled(false)
 	t.contextMenu.Actions().Add(t.mStatus)
 
+	// 刷新状态（已连接时可用）：请求服务端重新扫描并推送完整状态
+	t.mRefresh = walk.NewAction()
+	t.mRefresh.SetText("刷新状态")
+	t.mRefresh.SetEnabled(false)
+	t.mRefresh.Triggered().Attach(func() {
+		select {
+		case t.refreshCh <- struct{}{}:
+		default:
+		}
+	})
+	t.contextMenu.Actions().Add(t.mRefresh)
+
 	// 分隔符
 	t.contextMenu.Actions().Add(walk.NewSeparatorAction())
 
@@ -628,8 +659,29 @@ func (t *App) ServerSelectChan() <-chan config.ServerConfig {
 	return t.serverSelectCh
 }
 
+// AttachChan 返回连接会话 channel
+func (t *App) AttachChan() <-chan monitor.ProjectStatus {
+	return t.attachCh
+}
+
+// ReplyChan 返回已确认的会话回复 channel
+func (t *App) ReplyChan() <-chan monitor.ReplyRequest {
+	return t.replyCh
+}
+
+// RefreshChan 返回刷新状态 channel
+func (t *App) RefreshChan() <-chan struct{} {
+	return t.refreshCh
+}
+
+// RemoveChan 返回移除会话 channel
+func (t *App) RemoveChan() <-chan monitor.ProjectStatus {
+	return t.removeCh
+}
+
 // SetConnecting 设置正在连接状态
 func (t *App) SetConnecting(msg string) {
+	t.mRefresh.SetEnabled(false)
 	t.SetIcon("disconnected")
 	t.notifyIcon.SetToolTip("Claude Code Status - 正在连接...")
 	t.mStatus.SetText("正在连接 - " + msg)
@@ -637,6 +689,7 @@ func (t *App) SetConnecting(msg string) {
 
 // SetConnected 设置已连接状态
 func (t *App) SetConnected(msg string) {
+	t.mRefresh.SetEnabled(true)
 	t.SetIcon("input-needed")
 	t.notifyIcon.SetToolTip("") // 已连接时不显示 tooltip，使用悬浮卡片
 	t.mStatus.SetText("已连接 - " + msg)
@@ -646,6 +699,7 @@ func (t *App) SetConnected(msg string) {
 
 // SetDisconnected 设置用户主动断开状态
 func (t *App) SetDisconnected() {
+	t.mRefresh.SetEnabled(false)
 	t.SetIcon("disconnected")
 	t.mStatus.SetText("已断开连接")
 	t.notifyIcon.SetToolTip("Claude Code Status - 已断开连接")
@@ -655,6 +709,7 @@ func (t *App) SetDisconnected() {
 
 // SetError 设置错误状态
 func (t *App) SetError(errType string, msg string) {
+	t.mRefresh.SetEnabled(false)
 	t.SetIcon("disconnected")
 
 	var statusMsg string
@@ -698,6 +753,45 @@ func (t *App) SetTooltip(text string) {
 	}
 }
 
+// Notify 显示托盘气泡通知
+func (t *App) Notify(title string, message string) {
+	if t.notifyIcon == nil {
+		return
+	}
+	if err := t.notifyIcon.ShowInfo(title, message); err != nil {
+		logger.Error("显示通知失败: %v", err)
+	}
+}
+
+// UpdateUsageBlock 更新悬浮窗口顶部的用量窗口行
+func (t *App) UpdateUsageBlock(block *monitor.UsageBlock, limit int64) {
+	if t.popupWindow != nil {
+		t.popupWindow.UpdateUsageBlock(block, limit)
+	}
+}
+
+// SetLatency 在悬浮窗口顶部显示监控连接的往返延迟，0 表示隐藏
+func (t *App) SetLatency(rtt time.Duration) {
+	if t.popupWindow != nil {
+		t.popupWindow.SetLatency(rtt)
+	}
+}
+
+// SetClockSkew 将服务端时钟偏差交给悬浮窗口校正时长，超过阈值时在 tooltip 中提示
+func (t *App) SetClockSkew(skew time.Duration) {
+	if t.popupWindow != nil {
+		t.popupWindow.SetClockSkew(skew)
+	}
+	if monitor.SkewExceeded(skew) {
+		t.skewWarned = true
+		t.notifyIcon.SetToolTip("Claude Code Status - 时钟偏差: " + monitor.SkewLabel(skew) + "（已校正）")
+	} else if t.skewWarned {
+		// 只清除自己设置的提示
+		t.skewWarned = false
+		t.notifyIcon.SetToolTip("")
+	}
+}
+
 // UpdatePopup 更新悬浮窗口的会话状态
 func (t *App) UpdatePopup(statuses []monitor.ProjectStatus) {
 	t.statuses = statuses
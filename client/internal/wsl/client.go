@@ -28,7 +28,11 @@ var ErrVersionCheckTimeout = errors.New("version check timeout")
 type Client struct {
 	cfg       *config.Config
 	cmd       *exec.Cmd
+	control   *monitor.Control // 经 monitor.sh 的 stdin 发送控制命令
 	statusCh  chan []monitor.ProjectStatus
+	blockCh   chan *monitor.UsageBlock
+	eventCh   chan monitor.JournalEvent
+	clock     monitor.ClockSkew // 由 status / response 消息携带的服务端时间估计
 	errorCh   chan error
 	doneCh    chan struct{}
 	closeOnce sync.Once
@@ -40,6 +44,8 @@ func NewClient(cfg *config.Config) *Client {
 	return &Client{
 		cfg:       cfg,
 		statusCh:  make(chan []monitor.ProjectStatus, 10),
+		blockCh:   make(chan *monitor.UsageBlock, 1),
+		eventCh:   make(chan monitor.JournalEvent, monitor.EventBuffer),
 		errorCh:   make(chan error, 1),
 		doneCh:    make(chan struct{}),
 		versionOK: make(chan bool, 1),
@@ -79,6 +85,13 @@ func (c *Client) Start() error {
 		return fmt.Errorf("获取 stderr 失败: %w", err)
 	}
 
+	// 获取 stdin 作为控制通道
+	stdin, err := c.cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("获取 stdin 失败: %w", err)
+	}
+	c.control = monitor.NewControl(stdin)
+
 	// 启动命令
 	if err := c.cmd.Start(); err != nil {
 		return fmt.Errorf("启动 WSL 命令失败: %w", err)
@@ -130,6 +143,10 @@ func (c *Client) readOutput(r io.Reader) {
 			continue
 		}
 
+		if msg.Now > 0 {
+			c.clock.Observe(msg.Now, time.Now())
+		}
+
 		switch msg.Type {
 		case monitor.MsgTypeVersion:
 			if firstMessage {
@@ -162,13 +179,42 @@ func (c *Client) readOutput(r io.Reader) {
 				c.statusCh <- msg.Data
 			}
 
+		case monitor.MsgTypeUsageBlock:
+			// 只保留最新的用量窗口
+			select {
+			case c.blockCh <- msg.Block:
+			default:
+				select {
+				case <-c.blockCh:
+				default:
+				}
+				c.blockCh <- msg.Block
+			}
+
+		case monitor.MsgTypeEvent:
+			// 事件须逐个按序送达（先于随后的 replay 回复），channel 已满时只能丢弃
+			if msg.Event == nil {
+				continue
+			}
+			e := *msg.Event
+			e.Journal, e.Replay = msg.Journal, msg.Replay
+			select {
+			case c.eventCh <- e:
+			default:
+				logger.Error("readOutput: event channel full, dropping event seq=%d", e.Seq)
+			}
+
+		case monitor.MsgTypeResponse:
+			c.control.Deliver(msg)
+
 		case monitor.MsgTypeError:
 			select {
-			case c.errorCh <- fmt.Errorf(msg.Message):
+			case c.errorCh <- fmt.Errorf("%s", msg.Message):
 			default:
 			}
 		}
 	}
+	c.control.Close()
 }
 
 // readStderr 读取错误输出
@@ -182,16 +228,42 @@ func (c *Client) readStderr(r io.Reader) {
 
 // Close 关闭连接
 func (c *Client) Close() {
+	if c.control != nil {
+		c.control.Close()
+	}
 	if c.cmd != nil && c.cmd.Process != nil {
 		c.cmd.Process.Kill()
 	}
 }
 
+// Request 经 monitor.sh 的 stdin 发送控制命令并等待回复
+func (c *Client) Request(command string, args ...string) (string, error) {
+	if c.control == nil {
+		return "", monitor.ErrControlClosed
+	}
+	return c.control.Request(monitor.ControlTimeout, command, args...)
+}
+
 // StatusChan 返回状态 channel
 func (c *Client) StatusChan() <-chan []monitor.ProjectStatus {
 	return c.statusCh
 }
 
+// UsageBlockChan 返回用量窗口 channel
+func (c *Client) UsageBlockChan() <-chan *monitor.UsageBlock {
+	return c.blockCh
+}
+
+// EventChan 返回事件日志 channel
+func (c *Client) EventChan() <-chan monitor.JournalEvent {
+	return c.eventCh
+}
+
+// ClockSkew 返回平滑后的服务端时钟偏差
+func (c *Client) ClockSkew() time.Duration {
+	return c.clock.Offset()
+}
+
 // ErrorChan 返回错误 channel
 func (c *Client) ErrorChan() <-chan error {
 	return c.errorCh
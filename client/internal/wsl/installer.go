@@ -4,12 +4,15 @@ package wsl
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
+	"path"
 	"strings"
 
 	"claude-status/internal/config"
 	"claude-status/internal/installer"
 	"claude-status/internal/logger"
+	"claude-status/internal/monitor"
 )
 
 // Installer WSL 安装器
@@ -32,17 +35,10 @@ func (i *Installer) Close() {}
 
 // CheckDependencies 检查依赖
 func (i *Installer) CheckDependencies() (bool, string) {
-	output, err := i.runCommand("command -v inotifywait && command -v jq")
-	if err != nil {
-		missing := []string{}
-		if !strings.Contains(output, "inotifywait") {
-			missing = append(missing, "inotify-tools")
-		}
-		if !strings.Contains(output, "jq") {
-			missing = append(missing, "jq")
-		}
-		return false, fmt.Sprintf("缺少依赖: %s\n请在 WSL 中运行: sudo apt install %s",
-			strings.Join(missing, ", "), strings.Join(missing, " "))
+	output, _ := i.runCommand(installer.DependencyCheckCommand(i.cfg))
+	if missing := installer.MissingPackages(i.cfg, output); len(missing) > 0 {
+		return false, fmt.Sprintf("%s\n请在 WSL 中运行: sudo apt install %s",
+			installer.DependencyMessage(i.cfg, missing), strings.Join(missing, " "))
 	}
 	return true, ""
 }
@@ -56,21 +52,14 @@ func (i *Installer) Install() error {
 		return fmt.Errorf("创建目录失败: %w", err)
 	}
 
-	// 2. 上传脚本
-	if err := i.writeFile("~/.claude-status/hooks/status-hook.sh", installer.StatusHookScript); err != nil {
-		return fmt.Errorf("写入 status-hook.sh 失败: %w", err)
-	}
-
-	if err := i.writeFile("~/.claude-status/monitor.sh", installer.MonitorScript); err != nil {
-		return fmt.Errorf("写入 monitor.sh 失败: %w", err)
-	}
-
-	// 3. 设置执行权限
-	if _, err := i.runCommand("chmod +x ~/.claude-status/hooks/status-hook.sh ~/.claude-status/monitor.sh"); err != nil {
-		return fmt.Errorf("设置权限失败: %w", err)
+	// 2. 写入脚本（原子替换并校验，权限随文件一并设置）
+	for _, f := range append(installer.RemoteFiles(), installer.ConfigFiles(i.cfg)...) {
+		if err := i.writeFile(f.Path, f.Content, f.Mode); err != nil {
+			return fmt.Errorf("写入 %s 失败: %w", path.Base(f.Path), err)
+		}
 	}
 
-	// 4. 配置 Claude Code hooks
+	// 3. 配置 Claude Code hooks
 	if err := i.configureHooks(); err != nil {
 		return fmt.Errorf("配置 hooks 失败: %w", err)
 	}
@@ -88,13 +77,12 @@ func (i *Installer) Uninstall(purge bool) error {
 		logger.Info("开始 WSL 卸载...")
 	}
 
-	bashArgs := "bash -s"
+	var args []string
 	if purge {
-		bashArgs = "bash -s -- --purge"
+		args = append(args, "--purge")
 	}
 
-	cmd := fmt.Sprintf("%s << 'EOFSCRIPT'\n%s\nEOFSCRIPT", bashArgs, installer.UninstallRemoteScript)
-	output, err := i.runCommand(cmd)
+	output, err := i.RunScript(installer.UninstallRemoteScript, args...)
 	out := strings.TrimSpace(output)
 	if err != nil {
 		return fmt.Errorf("%w (output: %s)", err, out)
@@ -107,30 +95,49 @@ func (i *Installer) Uninstall(purge bool) error {
 	return nil
 }
 
-// runCommand 执行 WSL 命令
-func (i *Installer) runCommand(command string) (string, error) {
+// RunScript 在 WSL 中以 bash 执行脚本（通过 stdin 传入），返回合并后的输出
+func (i *Installer) RunScript(script string, args ...string) (string, error) {
+	cmd := exec.Command("wsl", i.wslArgs(monitor.BashScriptCommand(args...))...)
+	cmd.Stdin = strings.NewReader(script)
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// wslArgs 构造 wsl 命令参数，在指定发行版中以 bash -c 执行 command
+func (i *Installer) wslArgs(command string) []string {
 	args := []string{}
 	if i.cfg.WSL.Distro != "" {
 		args = append(args, "-d", i.cfg.WSL.Distro)
 	}
-	args = append(args, "--", "bash", "-c", command)
+	return append(args, "--", "bash", "-c", command)
+}
 
-	cmd := exec.Command("wsl", args...)
+// runCommand 执行 WSL 命令
+func (i *Installer) runCommand(command string) (string, error) {
+	cmd := exec.Command("wsl", i.wslArgs(command)...)
 	output, err := cmd.CombinedOutput()
 	return string(output), err
 }
 
-// writeFile 写入文件
-func (i *Installer) writeFile(path, content string) error {
-	// 使用 heredoc 写入文件
-	cmd := fmt.Sprintf("cat > %s << 'EOFSCRIPT'\n%s\nEOFSCRIPT", path, content)
-	_, err := i.runCommand(cmd)
-	return err
+// writeFile 通过 stdin 原子写入文件：临时文件 → fsync → 校验 → rename，
+// 不再依赖 heredoc，内容与路径中的特殊字符都不会破坏命令
+func (i *Installer) writeFile(remotePath, content string, mode os.FileMode) error {
+	script := installer.AtomicWriteCommand(remotePath, mode, installer.Checksum(content))
+	cmd := exec.Command("wsl", i.wslArgs(script)...)
+	cmd.Stdin = strings.NewReader(content)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w (output: %s)", err, strings.TrimSpace(string(output)))
+	}
+	return nil
 }
 
-// configureHooks 配置 hooks
+// configureHooks 配置 hooks（按安装模式写入 settings 文件或启用插件）
 func (i *Installer) configureHooks() error {
-	cmd := fmt.Sprintf("bash -s << 'EOFSCRIPT'\n%s\nEOFSCRIPT", installer.InstallRemoteScript)
-	_, err := i.runCommand(cmd)
-	return err
+	script, args := installer.ConfigureHooksCommand(i.cfg)
+	output, err := i.RunScript(script, args...)
+	if err != nil {
+		return fmt.Errorf("%w (output: %s)", err, strings.TrimSpace(output))
+	}
+	return nil
 }